@@ -0,0 +1,184 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell/termbox"
+)
+
+// errBackendClosed is returned by pollEvent once a backend has been closed.
+var errBackendClosed = errors.New("terminal closed")
+
+// CursorShape is the shape of the terminal cursor.  The values are the
+// DECSCUSR parameters.
+type CursorShape int
+
+// Cursor shapes.
+const (
+	CursorDefault   CursorShape = 0 // terminal default
+	CursorBlock     CursorShape = 2 // steady block
+	CursorUnderline CursorShape = 4 // steady underline
+	CursorBar       CursorShape = 6 // steady bar
+)
+
+// decscusr returns the escape sequence that sets the cursor shape.
+func decscusr(s CursorShape) string {
+	return fmt.Sprintf("\x1b[%d q", s)
+}
+
+// cursorShapeTerms are the terminfo names, or their prefixes, of terminals
+// that understand DECSCUSR.  Terminfo has no capability for it.
+var cursorShapeTerms = []string{
+	"alacritty", "foot", "kitty", "konsole", "rxvt-unicode", "screen",
+	"st-", "tmux", "vte", "wezterm", "xterm",
+}
+
+// hasCursorShapes returns true if the terminal with the provided terminfo
+// name can change the cursor shape.
+func hasCursorShapes(term string) bool {
+	for _, v := range cursorShapeTerms {
+		if strings.HasPrefix(term, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventType identifies the kind of event returned by a backend.
+type eventType int
+
+const (
+	eventNone   eventType = iota // nothing to do
+	eventKey                     // key stroke
+	eventResize                  // terminal changed size
+	eventError                   // backend is done, err is set
+)
+
+// event is a backend input event.
+type event struct {
+	typ eventType
+	key Key
+	err error
+}
+
+// backend is the interface to the physical terminal.  All calls, except
+// pollEvent, shall be made from queue context.  pollEvent is only called from
+// the key handler go routine.
+type backend interface {
+	init() error                // switch terminal to raw mode
+	close()                     // restore terminal
+	size() (int, int)           // terminal width and height
+	clear(Style)                // clear entire terminal
+	setCell(x, y int, c Cell)   // set a cell, will not show until flush
+	setCursor(x, y int)         // place cursor, -1 -1 hides it
+	setCursorShape(CursorShape) // cursor shape, will not show until flush
+	flush() (int, error)        // send pending output, returns bytes written
+	pollEvent() event           // block until there is an event
+}
+
+// termboxBackend drives the controlling terminal through termbox.
+type termboxBackend struct {
+	done  chan struct{}  // closed on close
+	tty   io.WriteCloser // terminal termbox writes to, nil without shapes
+	shape CursorShape    // requested cursor shape
+	sent  CursorShape    // cursor shape of the terminal
+}
+
+var (
+	_ backend = (*termboxBackend)(nil) // ensure interface is satisfied
+)
+
+func (t *termboxBackend) init() error {
+	err := termbox.Init()
+	if err != nil {
+		return err
+	}
+	t.done = make(chan struct{})
+	termbox.HideCursor()
+	termbox.SetInputMode(termbox.InputAlt) // this may need to become variable
+
+	// termbox does not know about cursor shapes, send them to the same
+	// terminal if it supports them
+	if hasCursorShapes(os.Getenv("TERM")) {
+		tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err == nil {
+			t.tty = tty
+		}
+	}
+	return nil
+}
+
+func (t *termboxBackend) close() {
+	if t.tty != nil {
+		if t.sent != CursorDefault {
+			_, _ = io.WriteString(t.tty, decscusr(CursorDefault))
+		}
+		t.tty.Close()
+		t.tty = nil
+	}
+	termbox.Close()
+	close(t.done)
+}
+
+func (t *termboxBackend) size() (int, int) {
+	return termbox.Size()
+}
+
+func (t *termboxBackend) clear(s Style) {
+	_, bg := s.Termbox()
+	termbox.Clear(bg, bg)
+}
+
+func (t *termboxBackend) setCell(x, y int, c Cell) {
+	fg, bg := c.Style.Termbox()
+	termbox.SetCell(x, y, c.Ch, fg, bg)
+}
+
+func (t *termboxBackend) setCursor(x, y int) {
+	termbox.SetCursor(x, y)
+}
+
+func (t *termboxBackend) setCursorShape(s CursorShape) {
+	t.shape = s
+}
+
+// flush does not know how many bytes termbox writes so it only counts the
+// cursor shape.  The shape is sent after termbox is done so that it is not
+// interleaved with termbox output.
+func (t *termboxBackend) flush() (int, error) {
+	err := termbox.Flush()
+	if err != nil || t.tty == nil || t.shape == t.sent {
+		return 0, err
+	}
+	t.sent = t.shape
+	return io.WriteString(t.tty, decscusr(t.shape))
+}
+
+func (t *termboxBackend) pollEvent() event {
+	for {
+		ev := termbox.PollEvent()
+		switch ev.Type {
+		case termbox.EventKey:
+			return event{typ: eventKey, key: KeyFromTermbox(ev)}
+		case termbox.EventResize:
+			return event{typ: eventResize}
+		case termbox.EventError:
+			return event{typ: eventError, err: ev.Err}
+		}
+
+		// termbox returns empty events once closed
+		select {
+		case <-t.done:
+			return event{typ: eventError, err: errBackendClosed}
+		default:
+		}
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"golang.org/x/text/unicode/bidi"
+)
+
+// The Unicode Bidirectional Algorithm (UAX #9) is applied to each visual line
+// at render time so that right to left text, i.e. Hebrew and Arabic, is
+// displayed properly.  It applies to everything that goes through
+// Window.print: widgets, frame captions and Canvas.Print alike.  The character classes come from x/text; the levels are
+// resolved here since x/text does not reorder.  Explicit embeddings, overrides
+// and isolates as well as bracket pairs (rule N0) are not supported, their
+// formatting characters are treated as neutrals.
+
+// bidiClasses returns the bidi class of each rune and true if any of them
+// requires right to left processing.
+func bidiClasses(text []rune) ([]bidi.Class, bool) {
+	classes := make([]bidi.Class, len(text))
+	rtl := false
+	for i, r := range text {
+		p, _ := bidi.LookupRune(r)
+		c := p.Class()
+		switch c {
+		case bidi.R, bidi.AL, bidi.AN:
+			rtl = true
+		case bidi.Control, bidi.LRO, bidi.RLO, bidi.LRE, bidi.RLE,
+			bidi.PDF, bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI:
+			c = bidi.ON
+		}
+		classes[i] = c
+	}
+	return classes, rtl
+}
+
+// strong returns the direction that a resolved class counts as for neutrals,
+// numbers count as right to left.
+func strong(c bidi.Class) (bidi.Class, bool) {
+	switch c {
+	case bidi.L:
+		return bidi.L, true
+	case bidi.R, bidi.AL, bidi.EN, bidi.AN:
+		return bidi.R, true
+	}
+	return 0, false
+}
+
+// isNeutral returns true for the classes that are resolved by rules N1 and N2.
+func isNeutral(c bidi.Class) bool {
+	switch c {
+	case bidi.B, bidi.S, bidi.WS, bidi.ON, bidi.BN:
+		return true
+	}
+	return false
+}
+
+// bidiLevels returns the embedding level of each rune of a line.  The
+// paragraph direction is set by the first strong character.  A nil slice is
+// returned if the line is entirely left to right.
+func bidiLevels(text []rune) []int {
+	orig, rtl := bidiClasses(text)
+	if !rtl {
+		return nil
+	}
+	c := make([]bidi.Class, len(orig))
+	copy(c, orig)
+
+	// P2, P3: paragraph level
+	base := 0
+	for _, v := range c {
+		if v == bidi.L {
+			break
+		}
+		if v == bidi.R || v == bidi.AL {
+			base = 1
+			break
+		}
+	}
+	sos := bidi.L
+	if base == 1 {
+		sos = bidi.R
+	}
+
+	// W1: non spacing marks take the class of the previous character
+	for i := range c {
+		if c[i] != bidi.NSM {
+			continue
+		}
+		if i == 0 {
+			c[i] = sos
+		} else {
+			c[i] = c[i-1]
+		}
+	}
+
+	// W2, W3: european numbers after arabic letters become arabic
+	last := sos
+	for i, v := range c {
+		switch v {
+		case bidi.L, bidi.R, bidi.AL:
+			last = v
+		case bidi.EN:
+			if last == bidi.AL {
+				c[i] = bidi.AN
+			}
+		}
+	}
+	for i, v := range c {
+		if v == bidi.AL {
+			c[i] = bidi.R
+		}
+	}
+
+	// W4: a single separator between two numbers of the same kind
+	for i := 1; i < len(c)-1; i++ {
+		prev, next := c[i-1], c[i+1]
+		switch {
+		case c[i] == bidi.ES && prev == bidi.EN && next == bidi.EN:
+			c[i] = bidi.EN
+		case c[i] == bidi.CS && prev == next &&
+			(prev == bidi.EN || prev == bidi.AN):
+			c[i] = prev
+		}
+	}
+
+	// W5: terminators adjacent to european numbers
+	for i := 0; i < len(c); i++ {
+		if c[i] != bidi.ET {
+			continue
+		}
+		j := i
+		for j < len(c) && c[j] == bidi.ET {
+			j++
+		}
+		if (i > 0 && c[i-1] == bidi.EN) || (j < len(c) && c[j] == bidi.EN) {
+			for k := i; k < j; k++ {
+				c[k] = bidi.EN
+			}
+		}
+		i = j
+	}
+
+	// W6: remaining separators and terminators
+	for i, v := range c {
+		switch v {
+		case bidi.ES, bidi.ET, bidi.CS:
+			c[i] = bidi.ON
+		}
+	}
+
+	// W7: european numbers in left to right context
+	last = sos
+	for i, v := range c {
+		switch v {
+		case bidi.L, bidi.R:
+			last = v
+		case bidi.EN:
+			if last == bidi.L {
+				c[i] = bidi.L
+			}
+		}
+	}
+
+	// N1, N2: neutrals take the direction of their surroundings
+	for i := 0; i < len(c); i++ {
+		if !isNeutral(c[i]) {
+			continue
+		}
+		j := i
+		for j < len(c) && isNeutral(c[j]) {
+			j++
+		}
+		before, after := sos, sos
+		if i > 0 {
+			if d, ok := strong(c[i-1]); ok {
+				before = d
+			}
+		}
+		if j < len(c) {
+			if d, ok := strong(c[j]); ok {
+				after = d
+			}
+		}
+		dir := sos
+		if before == after {
+			dir = before
+		}
+		for k := i; k < j; k++ {
+			c[k] = dir
+		}
+		i = j
+	}
+
+	// I1, I2: implicit levels
+	levels := make([]int, len(c))
+	for i, v := range c {
+		levels[i] = base
+		switch {
+		case base == 0 && v == bidi.R:
+			levels[i] = 1
+		case base == 0 && (v == bidi.EN || v == bidi.AN):
+			levels[i] = 2
+		case base == 1 && (v == bidi.L || v == bidi.EN || v == bidi.AN):
+			levels[i] = 2
+		}
+	}
+
+	// L1: separators and trailing whitespace return to the paragraph level
+	trailing := true
+	for i := len(orig) - 1; i >= 0; i-- {
+		switch orig[i] {
+		case bidi.S, bidi.B:
+			levels[i] = base
+			trailing = true
+		case bidi.WS, bidi.BN:
+			if trailing {
+				levels[i] = base
+			}
+		default:
+			trailing = false
+		}
+	}
+
+	return levels
+}
+
+// bidiOrder returns the logical index of the rune that is displayed at each
+// visual position of a line with the provided levels (rule L2).
+func bidiOrder(levels []int) []int {
+	order := make([]int, len(levels))
+	highest, lowestOdd := 0, -1
+	for i, l := range levels {
+		order[i] = i
+		if l > highest {
+			highest = l
+		}
+		if l%2 == 1 && (lowestOdd == -1 || l < lowestOdd) {
+			lowestOdd = l
+		}
+	}
+	if lowestOdd == -1 {
+		return order
+	}
+
+	// reverse sequences at each level from the highest to the lowest odd
+	// level; order is in visual order so look up levels through it
+	for level := highest; level >= lowestOdd; level-- {
+		for i := 0; i < len(order); i++ {
+			if levels[order[i]] < level {
+				continue
+			}
+			j := i
+			for j < len(order) && levels[order[j]] >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				order[a], order[b] = order[b], order[a]
+			}
+			i = j
+		}
+	}
+	return order
+}
+
+// mirror returns the mirrored bracket of r, which is displayed in right to
+// left text (rule L4).
+func mirror(r rune) rune {
+	if p, _ := bidi.LookupRune(r); !p.IsBracket() {
+		return r
+	}
+	m := []rune(bidi.ReverseString(string(r)))
+	if len(m) != 1 {
+		return r
+	}
+	return m[0]
+}
+
+// bidiCells reorders a line of cells from logical into visual order.
+func bidiCells(cells []Cell) []Cell {
+	text := make([]rune, len(cells))
+	for i := range cells {
+		text[i] = cells[i].Ch
+	}
+	levels := bidiLevels(text)
+	if levels == nil {
+		return cells
+	}
+	visual := make([]Cell, len(cells))
+	for i, j := range bidiOrder(levels) {
+		visual[i] = cells[j]
+		if levels[j]%2 == 1 {
+			visual[i].Ch = mirror(visual[i].Ch)
+		}
+	}
+	return visual
+}
+
+// bidiColumn returns the visual column of the cursor that is placed in front
+// of the rune at logical index at of a line, which is the cell of that rune.
+// At the end of the line the cursor is placed where the next rune in the
+// direction of the last one appears.
+func bidiColumn(text []rune, at int) int {
+	levels := bidiLevels(text)
+	if levels == nil || at > len(text) {
+		return at
+	}
+	order := bidiOrder(levels)
+	pos := make([]int, len(order))
+	for i, j := range order {
+		pos[j] = i
+	}
+	if at < len(text) {
+		return pos[at]
+	}
+	last := len(text) - 1
+	if levels[last]%2 == 1 {
+		return pos[last]
+	}
+	return pos[last] + 1
+}
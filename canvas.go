@@ -0,0 +1,176 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import "fmt"
+
+// Canvas is a rectangular drawing area of a window.  Coordinates are relative
+// to the top left corner of the canvas and all drawing is clipped to it.
+// Drawing goes into the window and will not show until flushed.  All Canvas
+// calls shall be made from queue context, i.e. from Render.
+type Canvas struct {
+	w *Window
+	r Rect // area in window coordinates, always within the window
+}
+
+// Canvas returns a canvas that covers the entire window.
+// Canvas shall be called from queue context.
+func (w *Window) Canvas() Canvas {
+	return Canvas{w: w, r: Rect{Width: w.x, Height: w.y}}
+}
+
+// Size returns the window width and height.
+// Size shall be called from queue context.
+func (w *Window) Size() (int, int) {
+	return w.x, w.y
+}
+
+// DefaultStyle returns the default style.  Unlike the DefaultStyle function it
+// does not block and is therefore safe to use in Render.
+func (c Canvas) DefaultStyle() Style {
+	return defaultStyle()
+}
+
+// Size returns the canvas width and height.
+func (c Canvas) Size() (int, int) {
+	return c.r.Width, c.r.Height
+}
+
+// Clip returns a canvas that covers r, in coordinates of c, clipped to c.
+func (c Canvas) Clip(r Rect) Canvas {
+	x0 := clamp(r.X, 0, c.r.Width)
+	y0 := clamp(r.Y, 0, c.r.Height)
+	x1 := clamp(r.X+r.Width, x0, c.r.Width)
+	y1 := clamp(r.Y+r.Height, y0, c.r.Height)
+	return Canvas{
+		w: c.w,
+		r: Rect{
+			X:      c.r.X + x0,
+			Y:      c.r.Y + y0,
+			Width:  x1 - x0,
+			Height: y1 - y0,
+		},
+	}
+}
+
+// clamp returns v limited to min and max.
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// in returns true if the coordinate is on the canvas.
+func (c Canvas) in(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.r.Width && y < c.r.Height
+}
+
+// SetCell sets the cell at the coordinate.
+func (c Canvas) SetCell(x, y int, ch rune, s Style) {
+	if !c.in(x, y) {
+		return
+	}
+	c.w.setCell(c.r.X+x, c.r.Y+y, Cell{Ch: ch, Style: s})
+}
+
+// Cell returns the cell at the coordinate.  Cells outside of the canvas are
+// returned empty.
+func (c Canvas) Cell(x, y int) Cell {
+	if !c.in(x, y) {
+		return Cell{}
+	}
+	return *c.w.getCell(c.r.X+x, c.r.Y+y)
+}
+
+// Print prints the formatted text starting at the coordinate and returns the
+// number of cells used.  Escape sequences understood by DecodeColor change
+// the style.  Like all text that ttk prints, the cells are reordered so that
+// right to left text displays properly; print padding separately since
+// trailing white space takes the direction of the text.
+func (c Canvas) Print(x, y int, s Style, format string,
+	args ...interface{}) int {
+	if !c.in(x, y) {
+		return 0
+	}
+	return c.w.print(c.r.X+x, c.r.Y+y, c.r.Width-x, s,
+		fmt.Sprintf(format, args...))
+}
+
+// Fill sets all cells in r, in coordinates of c, to ch.
+func (c Canvas) Fill(r Rect, ch rune, s Style) {
+	f := c.Clip(r)
+	for y := 0; y < f.r.Height; y++ {
+		for x := 0; x < f.r.Width; x++ {
+			f.SetCell(x, y, ch, s)
+		}
+	}
+}
+
+// SetCursor places the window cursor at the coordinate.  The cursor is
+// displayed if the window receives keyboard input.  A coordinate outside of
+// the canvas hides the cursor.
+func (c Canvas) SetCursor(x, y int) {
+	if !c.in(x, y) {
+		c.w.setCursor(-1, -1)
+		return
+	}
+	c.w.setCursor(c.r.X+x, c.r.Y+y)
+}
+
+// SetCursorShape sets the shape of the cursor that was placed with
+// SetCursor.  Placing the cursor returns it to the terminal default shape.
+func (c Canvas) SetCursorShape(s CursorShape) {
+	c.w.setCursorShape(s)
+}
+
+// Clear sets all cells of the canvas to spaces in the provided style.
+func (c Canvas) Clear(s Style) {
+	c.Fill(Rect{Width: c.r.Width, Height: c.r.Height}, ' ', s)
+}
+
+// HLine draws a horizontal line of n cells starting at the coordinate.
+func (c Canvas) HLine(x, y, n int, ch rune, s Style) {
+	c.Fill(Rect{X: x, Y: y, Width: n, Height: 1}, ch, s)
+}
+
+// VLine draws a vertical line of n cells starting at the coordinate.
+func (c Canvas) VLine(x, y, n int, ch rune, s Style) {
+	c.Fill(Rect{X: x, Y: y, Width: 1, Height: n}, ch, s)
+}
+
+// Border contains the runes that make up a box.
+type Border struct {
+	Horizontal  rune
+	Vertical    rune
+	TopLeft     rune
+	TopRight    rune
+	BottomLeft  rune
+	BottomRight rune
+}
+
+// BorderSingle draws boxes with single lines.
+var BorderSingle = Border{'─', '│', '┌', '┐', '└', '┘'}
+
+// Box draws the outline of r, in coordinates of c, with the border runes.  The
+// inside of the box is left alone.
+func (c Canvas) Box(r Rect, b Border, s Style) {
+	if r.Width < 1 || r.Height < 1 {
+		return
+	}
+	right := r.X + r.Width - 1
+	bottom := r.Y + r.Height - 1
+	c.HLine(r.X+1, r.Y, r.Width-2, b.Horizontal, s)
+	c.HLine(r.X+1, bottom, r.Width-2, b.Horizontal, s)
+	c.VLine(r.X, r.Y+1, r.Height-2, b.Vertical, s)
+	c.VLine(right, r.Y+1, r.Height-2, b.Vertical, s)
+	c.SetCell(r.X, r.Y, b.TopLeft, s)
+	c.SetCell(right, r.Y, b.TopRight, s)
+	c.SetCell(r.X, bottom, b.BottomLeft, s)
+	c.SetCell(right, bottom, b.BottomRight, s)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import "testing"
+
+// lines returns the canvas contents, one string per line.
+func lines(c Canvas) []string {
+	width, height := c.Size()
+	l := make([]string, height)
+	for y := range l {
+		for x := 0; x < width; x++ {
+			ch := c.Cell(x, y).Ch
+			if ch == 0 {
+				ch = ' '
+			}
+			l[y] += string(ch)
+		}
+	}
+	return l
+}
+
+func TestCanvas(t *testing.T) {
+	w, done := newTestWindow(8, 4)
+	defer done()
+	c := w.Canvas()
+	c.Clear(Style{})
+	c.Box(Rect{Width: 8, Height: 4}, BorderSingle, Style{})
+
+	// sub canvas coordinates are relative and clipped
+	inner := c.Clip(Rect{X: 1, Y: 1, Width: 6, Height: 2})
+	inner.Print(4, 0, Style{}, "hello")
+	inner.HLine(-2, 1, 20, '=', Style{})
+	inner.SetCell(6, 0, 'x', Style{})
+
+	want := []string{
+		"┌──────┐",
+		"│    he│",
+		"│======│",
+		"└──────┘",
+	}
+	got := lines(c)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("line %v: got %q want %q", i, got[i], want[i])
+		}
+	}
+
+	// escape sequences change the style and take no room
+	red, _ := Escape(AttrNA, ColorRed, AttrNA)
+	if n := c.Print(0, 0, Style{}, "%vab", red); n != 2 {
+		t.Fatalf("printed %v cells", n)
+	}
+	if cell := c.Cell(1, 0); cell.Ch != 'b' || cell.Fg != ColorRed {
+		t.Fatalf("unexpected cell %+v", cell)
+	}
+
+	// right to left text is reordered as in widgets
+	c.Clear(Style{})
+	if n := c.Print(0, 1, Style{}, "ab שלום"); n != 7 {
+		t.Fatalf("printed %v cells", n)
+	}
+	if got := lines(c)[1]; got != "ab םולש " {
+		t.Fatalf("right to left: got %q", got)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	w, done := newTestWindow(12, 4)
+	defer done()
+	f := w.AddFrame(0, 0, 0, 0, BorderRounded)
+	f.SetTitle("ab", JustifyCenter)
+	f.SetFooter("toolongfooter", JustifyRight)
+	e := w.AddEditText(0, 0, 0, "hi")
+	f.SetLayout(NewLayoutWidget(e, Constraint{}))
+	w.render()
+
+	// the edit is placed inside the border
+	if r := f.Inner(); r != (Rect{X: 1, Y: 1, Width: 10, Height: 2}) {
+		t.Fatalf("inner %+v", r)
+	}
+	if e.trueX != 1 || e.trueY != 1 || e.trueW != 10 {
+		t.Fatalf("edit at %v,%v width %v", e.trueX, e.trueY, e.trueW)
+	}
+
+	want := []string{
+		"╭─── ab ───╮",
+		"│hi        │",
+		"│          │",
+		"╰ toolongfo╯",
+	}
+	got := lines(w.Canvas())
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("line %v: got %q want %q", i, got[i], want[i])
+		}
+	}
+
+	// highlighted while the edit has focus
+	if c := w.getCell(0, 0); c.Flags&StyleBold == 0 {
+		t.Fatalf("border not highlighted")
+	}
+	w.focusIndex(-1)
+	if c := w.getCell(0, 0); c.Flags&StyleBold != 0 {
+		t.Fatalf("border still highlighted")
+	}
+}
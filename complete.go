@@ -0,0 +1,267 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ActionEditComplete completes the word in front of the cursor.  It is bound
+// to Tab in the WidgetEdit keymap; without a Completer Tab moves focus.
+const ActionEditComplete = "edit.complete"
+
+// maxPopupLines is the maximum height of the candidate popup.
+const maxPopupLines = 8
+
+// Completer is the interface that provides tab completion for an Edit.
+// Complete is called with the text and the cursor position, in runes, and
+// returns the candidates that replace the word in front of the cursor.  It is
+// called from queue context so be careful to not use blocking calls.
+type Completer interface {
+	Complete(text string, pos int) []string
+}
+
+// CompleterFunc is an adapter to use an ordinary function as a Completer.
+type CompleterFunc func(text string, pos int) []string
+
+// Complete calls f(text, pos).
+func (f CompleterFunc) Complete(text string, pos int) []string {
+	return f(text, pos)
+}
+
+// CompletionMode determines what happens when there are several candidates.
+type CompletionMode int
+
+const (
+	// CompleteCycle replaces the word with the next candidate on every
+	// Tab, after the last candidate the original word returns.
+	CompleteCycle CompletionMode = iota
+
+	// CompletePopup displays the candidates in a popup under the edit.
+	// Tab and Down select the next candidate, Up the previous one, Enter
+	// inserts it and Esc closes the popup.
+	CompletePopup
+)
+
+// SetCompleter enables tab completion.  A single candidate is inserted
+// directly, several candidates are handled according to mode.  A nil
+// completer disables completion.
+// SetCompleter shall be called from queue context.
+func (e *Edit) SetCompleter(c Completer, mode CompletionMode) {
+	e.endCompletion()
+	e.completer = c
+	e.compMode = mode
+}
+
+// SetWordBreaks sets the runes that separate words.  Completion replaces the
+// runes between the last word break in front of the cursor and the cursor.
+// The default is a space; i.e. add "/" to complete file names one directory
+// at a time.
+// SetWordBreaks shall be called from queue context.
+func (e *Edit) SetWordBreaks(breaks string) {
+	e.breaks = breaks
+}
+
+// Blur implements the Blurrer interface.  Completion ends when the edit loses
+// focus.
+func (e *Edit) Blur() {
+	e.endCompletion()
+}
+
+// moveCursor places the cursor in front of the rune at index i and scrolls
+// the text as little as possible so that it is visible.
+// moveCursor shall be called from queue context.
+func (e *Edit) moveCursor(i int) {
+	// do not scroll past the end of the text
+	if max := len(e.display) - e.trueW + 1; e.at > max {
+		e.at = max
+	}
+	if e.at < 0 {
+		e.at = 0
+	}
+	switch {
+	case i < e.at:
+		e.at = i
+	case i-e.at > e.trueW-1:
+		e.at = i - e.trueW + 1
+	}
+	e.cx = e.trueX + i - e.at
+}
+
+// replace replaces the runes from start to end with s as if the user typed it.
+// replace shall be called from queue context.
+func (e *Edit) replace(start, end int, s string) {
+	e.saveUndo(false)
+	text := make([]rune, 0, len(e.display)+len(s))
+	text = append(text, e.display[:start]...)
+	text = append(text, []rune(s)...)
+	text = append(text, e.display[end:]...)
+	if e.masked {
+		wipe(e.display[:cap(e.display)])
+	}
+	e.display = text
+	e.moveCursor(start + utf8.RuneCountInString(s))
+	e.Render()
+	e.setCursor()
+	e.changed()
+}
+
+// complete asks the completer for the candidates of the word in front of the
+// cursor.
+// complete shall be called from queue context.
+func (e *Edit) complete() bool {
+	if e.completer == nil || e.masked {
+		return false
+	}
+
+	pos := e.cx - e.trueX + e.at
+	start := pos
+	for start > 0 && !strings.ContainsRune(e.breaks, e.display[start-1]) {
+		start--
+	}
+	cands := e.completer.Complete(string(e.display), pos)
+	switch len(cands) {
+	case 0:
+		return true
+	case 1:
+		e.replace(start, pos, cands[0])
+		return true
+	}
+
+	e.cands = cands
+	e.candIdx = -1
+	e.compStart = start
+	e.compEnd = pos
+	e.compWord = string(e.display[start:pos])
+	announce("completions: %v", strings.Join(cands, ", "))
+	if e.compMode == CompletePopup {
+		e.openPopup()
+		return true
+	}
+	e.cycle(1)
+	return true
+}
+
+// cycle replaces the word with the next candidate if dir is positive,
+// otherwise with the previous one.  The original word sits between the last
+// and the first candidate.
+// cycle shall be called from queue context.
+func (e *Edit) cycle(dir int) {
+	n := len(e.cands) + 1
+	e.candIdx = (e.candIdx+1+dir+n)%n - 1
+	s := e.compWord
+	if e.candIdx >= 0 {
+		s = e.cands[e.candIdx]
+	}
+	e.replace(e.compStart, e.compEnd, s)
+	e.compEnd = e.compStart + utf8.RuneCountInString(s)
+}
+
+// completionKey handles keys while there are several candidates.  It returns
+// true if the key was handled and if so whether it was used.  Keys that do
+// not navigate the candidates end completion and are handled as usual.
+// completionKey shall be called from queue context.
+func (e *Edit) completionKey(ev Key) (bool, bool) {
+	if e.compMode == CompleteCycle {
+		if ev.Action == ActionEditComplete {
+			e.cycle(1)
+			return true, true
+		}
+		e.endCompletion()
+		return false, false
+	}
+
+	n := len(e.cands)
+	switch ev.Action {
+	case ActionEditComplete, ActionEditHistoryNext:
+		e.candIdx = (e.candIdx + 1) % n
+	case ActionEditHistoryPrevious:
+		e.candIdx = (e.candIdx - 1 + n) % n
+	case ActionEditSubmit:
+		s := e.cands[e.candIdx]
+		e.endCompletion()
+		e.replace(e.compStart, e.compEnd, s)
+		return true, true
+	case ActionEditCancel:
+		e.endCompletion()
+		return true, true
+	default:
+		e.endCompletion()
+		return false, false
+	}
+	e.popup.render()
+	return true, true
+}
+
+// endCompletion forgets the candidates and closes the popup.
+// endCompletion shall be called from queue context.
+func (e *Edit) endCompletion() {
+	e.cands = nil
+	if e.popup != nil {
+		closeOverlay(e.popup)
+		e.popup = nil
+	}
+}
+
+// openPopup displays the candidates under the word that is completed.
+// openPopup shall be called from queue context.
+func (e *Edit) openPopup() {
+	width := 0
+	for _, c := range e.cands {
+		if l := utf8.RuneCountInString(c); l > width {
+			width = l
+		}
+	}
+	height := len(e.cands)
+	if height > maxPopupLines {
+		height = maxPopupLines
+	}
+	x := e.compStart - e.at
+	if x < 0 {
+		x = 0
+	}
+
+	e.candIdx = 0
+	e.popup = newOverlay(&completionPopup{e: e}, Overlay{
+		Widget: e,
+		X:      x,
+		Y:      1,
+		Width:  width + 2,
+		Height: height,
+	})
+}
+
+// completionPopup is the Windower of the candidate popup.
+type completionPopup struct {
+	e *Edit
+}
+
+func (p *completionPopup) Init(w *Window) {}
+
+// Render draws the candidates, the selected one is highlighted and scrolled
+// into view.
+func (p *completionPopup) Render(w *Window) {
+	c := w.Canvas()
+	width, height := c.Size()
+	top := 0
+	if p.e.candIdx >= height {
+		top = p.e.candIdx - height + 1
+	}
+	for y := 0; y < height; y++ {
+		i := top + y
+		s := defaultStyle().Reverse()
+		if i == p.e.candIdx {
+			s = defaultStyle()
+		}
+		line := c.Clip(Rect{Y: y, Width: width, Height: 1})
+		line.Clear(s)
+		if i < len(p.e.cands) {
+			line.Print(1, 0, s, "%v", p.e.cands[i])
+		}
+	}
+}
+
+func (p *completionPopup) KeyHandler(w *Window, k Key) {}
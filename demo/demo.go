@@ -7,9 +7,9 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/companyzero/ttk"
-	"github.com/gdamore/tcell/termbox"
 )
 
 var (
@@ -29,10 +29,11 @@ type mainWindow struct {
 
 // called from queue
 func (mw *mainWindow) Init(w *ttk.Window) {
+	w.SetTitle("main")
 	mw.l = w.AddLabel(2, 2, "hello world")
-	mw.l.SetAttributes(ttk.Attributes{
-		Fg: termbox.ColorYellow,
-		Bg: termbox.ColorBlue,
+	mw.l.SetStyle(ttk.Style{
+		Fg: ttk.ColorYellow,
+		Bg: ttk.ColorBlue,
 	})
 
 	// edit box
@@ -42,33 +43,67 @@ func (mw *mainWindow) Init(w *ttk.Window) {
 	var s2 string
 	mw.e2 = w.AddEdit(4, 5, -4, &s2)
 
-	var s3 string
-	mw.e3 = w.AddEdit(3, 6, -8, &s3)
+	// passphrase
+	mw.e3 = w.AddEditText(3, 6, -8, "")
+	mw.e3.SetMasked(true, '*')
+	mw.e3.OnSubmit(func(string) bool {
+		// the text is wiped after this returns, wipe the copy too
+		b := mw.e3.Bytes()
+		mw.s.SetText("status: passphrase of %v bytes", len(b))
+		mw.s.Render()
+		for i := range b {
+			b[i] = 0
+		}
+		return false // let enter move focus
+	})
 
-	var s4 string
-	mw.e4 = w.AddEdit(0, 8, 0, &s4)
+	mw.e4 = w.AddEditText(0, 8, 0, "")
+	mw.e4.SetName("message")
+	mw.e4.SetHistory(ttk.NewHistory(100))
+	mw.e4.SetCompleter(ttk.CompleterFunc(func(text string, pos int) []string {
+		// complete commands at the start of the line
+		var cands []string
+		for _, c := range []string{"/help", "/join", "/jump", "/quit"} {
+			if strings.HasPrefix(c, string([]rune(text)[:pos])) {
+				cands = append(cands, c)
+			}
+		}
+		return cands
+	}), ttk.CompletePopup)
+	mw.e4.OnSubmit(func(s string) bool {
+		// move text into the list
+		mw.list.Append("%v", s)
+		mw.list.Render()
+		mw.e4.SetValue("", true)
+		mw.e4.Render()
+		return true
+	})
 
 	// list box
-	mw.list = w.AddList(10, 10, 0, -2)
+	mw.list = w.AddList(0, 10, 0, -2)
 	mw.list.Append("this is a list box with some content")
 
 	// title
 	mw.t = w.AddStatus(0, ttk.JustifyCenter, "title %v", 12)
-	mw.t.SetAttributes(ttk.Attributes{
-		Fg: termbox.ColorBlack,
-		Bg: termbox.ColorGreen,
+	mw.t.SetStyle(ttk.Style{
+		Fg: ttk.ColorBlack,
+		Bg: ttk.ColorGreen,
 	})
 
 	// status
 	mw.s = w.AddStatus(-1, ttk.JustifyRight, "status: %v", "OMG")
-	mw.s.SetAttributes(ttk.Attributes{
-		Fg: termbox.ColorBlack,
-		Bg: termbox.ColorYellow,
+	mw.s.SetStyle(ttk.Style{
+		Fg: ttk.ColorBlack,
+		Bg: ttk.ColorYellow,
 	})
 	ttk.Flush()
 }
 
 func (mw *mainWindow) Render(w *ttk.Window) {
+	// separate the edits from the list
+	c := w.Canvas()
+	width, _ := c.Size()
+	c.HLine(0, 9, width, '─', c.DefaultStyle())
 }
 
 // called from queue
@@ -76,31 +111,71 @@ func (mw *mainWindow) KeyHandler(w *ttk.Window, k ttk.Key) {
 }
 
 type secondWindow struct {
-	l *ttk.Label
-	e *ttk.Edit
+	l  *ttk.Label
+	ta *ttk.TextArea
+	e  *ttk.Edit
 }
 
 // called from queue
 func (sw *secondWindow) Init(w *ttk.Window) {
+	w.SetTitle("second")
 	sw.l = w.AddLabel(2, 2, "hello world from #2")
-	sw.l.SetAttributes(ttk.Attributes{
-		Fg: termbox.ColorRed,
-		Bg: termbox.ColorCyan,
+	sw.l.SetStyle(ttk.Style{
+		Fg: ttk.ColorRed,
+		Bg: ttk.ColorCyan,
 	})
 
+	// text area, Alt-Enter moves the text into the edit box
+	sw.ta = w.AddTextArea(2, 4, -2, 8, "type a long message here")
+	sw.ta.SetName("message")
+
 	// edit box
 	var s string = "abc"
 	sw.e = w.AddEdit(2, 14, -2, &s)
+	sw.ta.OnSubmit(func(text string) bool {
+		sw.e.SetValue(text, true)
+		sw.e.Render()
+		sw.ta.SetValue("", false)
+		sw.ta.Render()
+		return true
+	})
 	ttk.Flush()
 }
 
 func (sw *secondWindow) Render(w *ttk.Window) {
+	c := w.Canvas()
+	width, height := c.Size()
+	c.Clear(c.DefaultStyle())
+	c.Box(ttk.Rect{Width: width, Height: height}, ttk.BorderSingle,
+		ttk.Style{Fg: ttk.ColorBlue})
 }
 
 // called from queue
 func (mw *secondWindow) KeyHandler(w *ttk.Window, k ttk.Key) {
 }
 
+type popup struct {
+	f *ttk.Frame
+	e *ttk.Edit
+}
+
+// called from queue
+func (p *popup) Init(w *ttk.Window) {
+	p.f = w.AddFrame(0, 0, 0, 0, ttk.BorderRounded)
+	p.f.SetTitle("popup", ttk.JustifyCenter)
+	p.f.SetFooter("esc closes", ttk.JustifyRight)
+
+	p.e = w.AddEditText(0, 0, 0, "")
+	p.f.SetLayout(ttk.NewLayoutWidget(p.e, ttk.Constraint{}))
+}
+
+func (p *popup) Render(w *ttk.Window) {
+}
+
+// called from queue
+func (p *popup) KeyHandler(w *ttk.Window, k ttk.Key) {
+}
+
 func _main() error {
 	err := ttk.Init()
 	if err != nil {
@@ -116,22 +191,85 @@ func _main() error {
 
 	ttk.Focus(mw)
 
-	for {
+	var (
+		pw     *ttk.Window
+		tiled  bool
+		linear bool
+		quit   bool
+	)
+	for _, b := range []struct {
+		keys   string
+		action string
+		f      func(ttk.Key)
+	}{
+		{"F1", "focus.main", func(ttk.Key) { ttk.Focus(mw) }},
+		{"F2", "focus.second", func(ttk.Key) { ttk.Focus(sw) }},
+		{"F3", "popup", func(ttk.Key) {
+			if pw == nil {
+				pw = ttk.NewOverlay(&popup{}, ttk.Overlay{
+					Width:  30,
+					Height: 3,
+					Center: true,
+					Modal:  true,
+				})
+			}
+		}},
+		{"F4", "tile", func(ttk.Key) {
+			// toggle side by side panes
+			tiled = !tiled
+			if tiled {
+				ttk.Tile(ttk.NewSplit(ttk.SplitColumns, ttk.PaneSize{},
+					ttk.NewPane(mw, ttk.PaneSize{Ratio: 2}),
+					ttk.NewPane(sw, ttk.PaneSize{Ratio: 1})))
+			} else {
+				ttk.Tile(nil)
+			}
+		}},
+		{"F5", ttk.ActionPaneNext, nil},
+		{"F6", ttk.ActionPaneShrink, nil},
+		{"F7", ttk.ActionPaneGrow, nil},
+		{"F8", "linear", func(ttk.Key) {
+			// toggle screen reader mode
+			linear = !linear
+			if linear {
+				ttk.SetLinear(os.Stdout)
+			} else {
+				ttk.SetLinear(nil)
+			}
+		}},
+		{"F9", ttk.ActionReadStatus, nil},
+		{"Alt-a", "focus.active", func(ttk.Key) { ttk.FocusNextActive() }},
+		{"Ctrl-X Ctrl-C", "quit", func(ttk.Key) { quit = true }},
+		{"Ctrl-Q", "quit", nil},
+	} {
+		err := ttk.GlobalKeymap().Bind(b.keys, b.action)
+		if err != nil {
+			return err
+		}
+		if b.f != nil {
+			ttk.RegisterAction(b.action, b.f)
+		}
+	}
+
+	for !quit {
 		key := <-ttk.KeyChannel()
+		if pw != nil && key.Key == ttk.KeyEsc {
+			ttk.CloseOverlay(pw)
+			pw = nil
+			continue
+		}
+		if ttk.HandleAction(key) {
+			continue
+		}
 		switch key.Key {
-		case termbox.KeyF1:
-			ttk.Focus(mw)
-		case termbox.KeyF2:
-			ttk.Focus(sw)
-		case termbox.KeyCtrlQ:
-			return nil
-		case termbox.KeyEnter:
+		case ttk.KeyEnter:
 			// XXX check if mw is focused
 			mw.FocusNext()
 		default:
 			ttk.ForwardKey(key)
 		}
 	}
+	return nil
 }
 
 func main() {
@@ -5,9 +5,8 @@
 package ttk
 
 import (
+	"fmt"
 	"strings"
-
-	"github.com/gdamore/tcell/termbox"
 )
 
 // WidgetEdit uniquely identifies the edit widget.
@@ -19,28 +18,131 @@ var (
 	_ Widgeter = (*Edit)(nil) // ensure interface is satisfied
 )
 
-// init registers the Edit Widget.
+// Edit actions.  They are bound in the WidgetEdit keymap.
+const (
+	ActionEditHome      = "edit.home"      // cursor to begin of text
+	ActionEditEnd       = "edit.end"       // cursor to end of text
+	ActionEditKillLine  = "edit.kill-line" // kill text
+	ActionEditLeft      = "edit.left"      // cursor left
+	ActionEditRight     = "edit.right"     // cursor right
+	ActionEditDelete    = "edit.delete"    // erase character under cursor
+	ActionEditBackspace = "edit.backspace" // erase character before cursor
+	ActionEditSubmit    = "edit.submit"    // text is done
+	ActionEditCancel    = "edit.cancel"    // editing is abandoned
+	ActionEditOverwrite = "edit.overwrite" // toggle overwrite mode
+
+	ActionEditHistoryPrevious = "edit.history-previous" // recall older line
+	ActionEditHistoryNext     = "edit.history-next"     // recall newer line
+	ActionEditSearch          = "edit.search"           // reverse search
+)
+
+// Prompts of the reverse incremental history search.
+const (
+	searchPrompt       = "(reverse-i-search)`"
+	searchFailedPrompt = "(failed reverse-i-search)`"
+)
+
+// init registers the Edit Widget and its default key bindings.
 func init() {
 	registeredWidgets[WidgetEdit] = NewEdit
+
+	km := WidgetKeymap(WidgetEdit)
+	for _, b := range []struct {
+		keys   string
+		action string
+	}{
+		{"Ctrl-A", ActionEditHome},
+		{"Home", ActionEditHome},
+		{"Ctrl-E", ActionEditEnd},
+		{"End", ActionEditEnd},
+		{"Ctrl-U", ActionEditKillLine},
+		{"Left", ActionEditLeft},
+		{"Right", ActionEditRight},
+		{"Delete", ActionEditDelete},
+		{"Ctrl-H", ActionEditBackspace},
+		{"Backspace", ActionEditBackspace},
+		{"Enter", ActionEditSubmit},
+		{"Esc", ActionEditCancel},
+		{"Insert", ActionEditOverwrite},
+		{"Up", ActionEditHistoryPrevious},
+		{"Down", ActionEditHistoryNext},
+		{"Ctrl-R", ActionEditSearch},
+		{"Tab", ActionEditComplete},
+		{"Alt-b", ActionEditWordLeft},
+		{"Ctrl-Left", ActionEditWordLeft},
+		{"Alt-f", ActionEditWordRight},
+		{"Ctrl-Right", ActionEditWordRight},
+		{"Ctrl-W", ActionEditKillWordBackward},
+		{"Alt-d", ActionEditKillWord},
+		{"Ctrl-K", ActionEditKillToEnd},
+		{"Ctrl-Y", ActionEditYank},
+		{"Alt-y", ActionEditYankPop},
+		{"Ctrl-T", ActionEditTranspose},
+		{"Ctrl-L", ActionEditRedraw},
+		{"Ctrl-_", ActionEditUndo},
+		{"Ctrl-Z", ActionEditUndo},
+		{"Alt-_", ActionEditRedo},
+		{"Alt-z", ActionEditRedo},
+	} {
+		if err := km.Bind(b.keys, b.action); err != nil {
+			panic(err)
+		}
+	}
 }
 
-// Edit is a text entry widget.  It prints the contents of target onto the
-// window.  Note: all spaces are trimmed before and after the target string.
+// Edit is a text entry widget.  It prints the text onto the window and
+// reports changes through the OnChange, OnSubmit and OnCancel callbacks.
 type Edit struct {
 	Widget
 	trueX      int     // actual x coordinate
 	trueY      int     // actual y coordinate
 	trueW      int     // actual width
-	target     *string // result value of action
-	display    []rune  // target as runes
+	target     *string // updated on every change, may be nil
+	display    []rune  // text as runes
 	at         int     // start of displayed text
 	width      int     // prefered widget width
 	cx         int     // current cursor x position
 	cy         int     // current cursor y position
-	prevX      int     // previous window max x
-	prevY      int     // previous window max y
 	visibility Visibility
-	attr       Attributes
+	style      Style
+	overwrite  bool        // typed runes replace the rune under the cursor
+	insertC    CursorShape // cursor shape in insert mode
+	overwriteC CursorShape // cursor shape in overwrite mode
+	last       string      // action of the previous key
+	masked     bool        // text is not displayed nor copied
+	mask       rune        // displayed for every rune if masked, 0 for none
+
+	// undo
+	undo      []editState // states before changes, newest last
+	redo      []editState // states before undo, newest last
+	undoDepth int         // maximum length of undo
+	typing    bool        // last change was typed
+
+	// history, nil if none
+	history   *History
+	histIdx   int    // recalled line, -1 while editing the draft
+	draft     string // text that was typed before recalling
+	searching bool   // reverse incremental search in progress
+	query     []rune // search text
+	match     int    // matching line, -1 if none
+	failed    bool   // query does not match beyond match
+	saved     string // text before the search started
+
+	// completion, nil if none
+	completer Completer
+	compMode  CompletionMode
+	breaks    string   // runes that separate words
+	cands     []string // candidates while completing, nil otherwise
+	candIdx   int      // selected candidate, -1 is the original word
+	compStart int      // index of the word that is completed
+	compEnd   int      // index after the inserted candidate
+	compWord  string   // word that is completed
+	popup     *Window  // candidate popup, nil if closed
+
+	// callbacks, called from queue context
+	onChange func(string)
+	onSubmit func(string) bool
+	onCancel func()
 }
 
 func (e *Edit) Visibility(op Visibility) Visibility {
@@ -59,7 +161,7 @@ func (e *Edit) Visibility(op Visibility) Visibility {
 }
 
 func (e *Edit) clear() {
-	e.w.printf(e.trueX, e.trueY, defaultAttributes(), strings.Repeat(" ", e.trueW))
+	e.w.printf(e.trueX, e.trueY, defaultStyle(), strings.Repeat(" ", e.trueW))
 }
 
 // Render implements the Render interface.  This is called from queue context
@@ -69,16 +171,58 @@ func (e *Edit) Render() {
 		e.clear()
 		return
 	}
+	if e.searching {
+		e.renderSearch()
+		return
+	}
+	if e.masked {
+		e.renderMasked()
+		return
+	}
+
+	// print text separately so that the filler is not reordered with it
+	n := e.w.print(e.trueX, e.trueY, e.trueW, e.style, string(e.visible()))
+	e.w.print(e.trueX+n, e.trueY, e.trueW-n, e.style,
+		strings.Repeat(" ", e.trueW-n))
+}
 
-	filler := ""
+// visible returns the displayed part of the text.
+func (e *Edit) visible() []rune {
+	if e.at > len(e.display) {
+		// no room to display anything
+		return nil
+	}
 	l := e.display[e.at:]
 	if len(l) > e.trueW {
-		l = e.display[e.at : e.at+e.trueW]
+		l = l[:e.trueW]
+	}
+	return l
+}
+
+// setCursor places the cursor in front of the rune at cx.  The cursor moves
+// in logical order so in right to left text it is displayed at the visual
+// position of that rune.
+// setCursor shall be called from queue context.
+func (e *Edit) setCursor() {
+	if e.searching {
+		e.setSearchCursor()
+		return
+	}
+
+	x := e.cx
+	switch {
+	case e.masked && e.mask == 0:
+		x = e.trueX
+	case e.masked:
+	case e.cx >= e.trueX:
+		x = e.trueX + bidiColumn(e.visible(), e.cx-e.trueX)
+	}
+	e.w.setCursor(x, e.cy)
+	if e.overwrite {
+		e.w.setCursorShape(e.overwriteC)
 	} else {
-		// just erase right hand side
-		filler = strings.Repeat(" ", e.trueW-len(l))
+		e.w.setCursorShape(e.insertC)
 	}
-	e.w.printf(e.trueX, e.trueY, e.attr, "%v%v", string(l), filler)
 }
 
 func insert(slice []rune, index int, value rune) []rune {
@@ -94,36 +238,50 @@ func insert(slice []rune, index int, value rune) []rune {
 
 // KeyHandler implements the interface.  This is called from queue context
 // so be careful to not use blocking calls.
-func (e *Edit) KeyHandler(ev termbox.Event) bool {
+func (e *Edit) KeyHandler(ev Key) bool {
 	var inString int
 
-	switch ev.Key {
-	case termbox.KeyCtrlA, termbox.KeyHome:
+	if e.searching {
+		return e.searchKey(ev)
+	}
+	if e.cands != nil {
+		if handled, used := e.completionKey(ev); handled {
+			return used
+		}
+	}
+
+	last := e.last
+	e.last = ev.Action
+	if ev.Action != "" {
+		e.typing = false
+	}
+	if e.readlineKey(ev, last) {
+		return true
+	}
+
+	switch ev.Action {
+	case ActionEditHome:
 		e.cx = e.trueX
 		e.at = 0
-		setCursor(e.cx, e.cy)
+		e.setCursor()
 		e.Render()
 		return true
-	case termbox.KeyCtrlE, termbox.KeyEnd:
+	case ActionEditEnd:
 		if len(e.display) < e.trueW-1 {
 			// no need to call display
 			e.cx = e.trueX + len(e.display) - e.at
-			setCursor(e.cx, e.cy)
+			e.setCursor()
 			return true
 		}
 		e.cx = e.trueX + e.trueW - 1
 		e.at = len(e.display) - e.trueW + 1
-		setCursor(e.cx, e.cy)
+		e.setCursor()
 		e.Render()
 		return true
-	case termbox.KeyCtrlU:
-		e.cx = e.trueX
-		e.at = 0
-		e.display = []rune("")
-		setCursor(e.cx, e.cy)
-		e.Render()
+	case ActionEditKillLine:
+		e.kill(0, len(e.display), last)
 		return true
-	case termbox.KeyArrowRight:
+	case ActionEditRight:
 		// check to see if we have content on the right hand side
 		if e.cx-e.trueX == len(e.display[e.at:]) {
 			return true
@@ -138,11 +296,12 @@ func (e *Edit) KeyHandler(ev termbox.Event) bool {
 			}
 			e.at++
 			e.Render()
+			e.setCursor()
 			return true
 		}
-		setCursor(e.cx, e.cy)
+		e.setCursor()
 		return true
-	case termbox.KeyArrowLeft:
+	case ActionEditLeft:
 		e.cx--
 		if e.cx < e.trueX {
 			e.cx = e.trueX
@@ -152,23 +311,27 @@ func (e *Edit) KeyHandler(ev termbox.Event) bool {
 			}
 			e.Render()
 		}
-		setCursor(e.cx, e.cy)
+		e.setCursor()
 		return true
-	case termbox.KeyDelete:
+	case ActionEditDelete:
 		inString = e.cx - e.trueX + e.at
 		if len(e.display) == inString {
 			return true
 		}
+		e.saveUndo(false)
 		// remove from slice
 		e.display = append(e.display[:inString],
 			e.display[inString+1:]...)
 		e.Render()
+		e.setCursor()
+		e.changed()
 		return true
-	case termbox.KeyBackspace, termbox.KeyBackspace2:
+	case ActionEditBackspace:
 		inString = e.cx - e.trueX + e.at
 		if inString <= 0 {
 			return true
 		}
+		e.saveUndo(false)
 		e.display = append(e.display[:inString-1],
 			e.display[inString:]...)
 
@@ -185,17 +348,54 @@ func (e *Edit) KeyHandler(ev termbox.Event) bool {
 		} else {
 			e.cx--
 		}
-		setCursor(e.cx, e.cy)
+		e.setCursor()
 		e.Render()
+		e.changed()
 		return true
-	case termbox.KeySpace:
-		// use space
-		ev.Ch = ' '
-	case termbox.KeyEnter:
-		*e.target = string(e.display)
+	case ActionEditSubmit:
+		if e.masked {
+			return e.submitMasked()
+		}
+		if e.history != nil {
+			e.history.Add(string(e.display))
+			e.histIdx = -1
+			e.draft = ""
+		}
+		if e.onSubmit != nil {
+			return e.onSubmit(string(e.display))
+		}
 		// return false and let the application decide if it wants
 		// to consume the action
 		return false
+	case ActionEditCancel:
+		if e.onCancel != nil {
+			e.onCancel()
+			return true
+		}
+		return false
+	case ActionEditOverwrite:
+		e.overwrite = !e.overwrite
+		e.setCursor()
+		return true
+	case ActionEditHistoryPrevious:
+		return e.historyStep(-1)
+	case ActionEditHistoryNext:
+		return e.historyStep(1)
+	case ActionEditSearch:
+		return e.startSearch()
+	case ActionEditComplete:
+		return e.complete()
+	case ActionEditUndo:
+		e.restore(&e.undo, &e.redo)
+		return true
+	case ActionEditRedo:
+		e.restore(&e.redo, &e.undo)
+		return true
+	}
+
+	if ev.Key == KeySpace {
+		// use space
+		ev.Ch = ' '
 	}
 
 	// normal runes are displayed and stored
@@ -206,19 +406,31 @@ func (e *Edit) KeyHandler(ev termbox.Event) bool {
 		return false
 	}
 
+	e.saveUndo(true)
+	e.reserve(1)
 	inString = e.cx - e.trueX + e.at
-	e.display = insert(e.display, inString, ev.Ch)
+	if e.overwrite && inString < len(e.display) {
+		e.display[inString] = ev.Ch
+	} else {
+		e.display = insert(e.display, inString, ev.Ch)
+	}
 	if e.cx < e.trueW+e.trueX-1 {
 		e.cx++
-		setCursor(e.cx, e.cy)
 	} else {
 		e.at++
 	}
 
 	e.Render()
+	e.setCursor()
+	e.changed()
 	return true
 }
 
+// position returns the window coordinates of the widget.
+func (e *Edit) position() (int, int) {
+	return e.trueX, e.trueY
+}
+
 // CanFocus implements the interface.  This is called from queue context
 // so be careful to not use blocking calls.
 func (e *Edit) CanFocus() bool {
@@ -234,128 +446,427 @@ func (e *Edit) Focus() {
 		e.cy = e.trueY
 		e.at = 0
 	}
-	setCursor(e.cx, e.cy)
+	e.setCursor()
 }
 
 // NewEdit is the Edit initializer.  This call implements the NewWidget
 // convention by taking a *Window and and an anchor point to render the widget.
 func NewEdit(w *Window, x, y int) (Widgeter, error) {
 	return &Edit{
-		Widget: MakeWidget(w, x, y),
+		Widget:     MakeWidget(w, x, y),
+		insertC:    CursorBar,
+		overwriteC: CursorBlock,
+		histIdx:    -1,
+		breaks:     " ",
+		undoDepth:  defaultUndoDepth,
 	}, nil
 }
 
-// SetAttributes sets the Attributes.  This will not be displayed immediately.
-// SetAttributes shall be called from queue context.
-func (e *Edit) SetAttributes(a Attributes) {
-	e.attr = a
+// SetStyle sets the Style.  This will not be displayed immediately.
+// SetStyle shall be called from queue context.
+func (e *Edit) SetStyle(s Style) {
+	e.style = s
+}
+
+// Overwrite returns true if the edit is in overwrite mode.
+// Overwrite shall be called from queue context.
+func (e *Edit) Overwrite() bool {
+	return e.overwrite
+}
+
+// SetOverwrite switches between overwrite and insert mode.  In overwrite mode
+// typed runes replace the rune under the cursor.  The Insert key toggles the
+// mode.
+// SetOverwrite shall be called from queue context.
+func (e *Edit) SetOverwrite(overwrite bool) {
+	e.overwrite = overwrite
+	if e.cx >= 0 && e.cy >= 0 && e.w.focusedWidget() == Widgeter(e) {
+		e.setCursor()
+	}
+}
+
+// SetCursorShapes sets the cursor shapes that are used in insert and in
+// overwrite mode.  The defaults are CursorBar and CursorBlock.
+// SetCursorShapes shall be called from queue context.
+func (e *Edit) SetCursorShapes(insert, overwrite CursorShape) {
+	e.insertC = insert
+	e.overwriteC = overwrite
 }
 
 // GetText returns the edit text.
 // GetText shall be called from queue context.
 func (e *Edit) GetText() string {
+	if e.masked {
+		return ""
+	}
 	return string(e.display)
 }
 
-// SetText sets the edit text.  if end is set to true the cursor and text will
-// be set to the end of the string.  This will not be displayed immediately.
+// Describe implements the Describer interface.
+func (e *Edit) Describe() string {
+	if e.masked {
+		return fmt.Sprintf("edit: %v, masked", e.name)
+	}
+	if len(e.display) == 0 {
+		return fmt.Sprintf("edit: %v, empty", e.name)
+	}
+	return fmt.Sprintf("edit: %v, contents %v", e.name, string(e.display))
+}
+
+// changed updates the target and calls the OnChange callback.
+// changed shall be called from queue context.
+func (e *Edit) changed() {
+	if e.masked {
+		// deleting leaves runes behind the text
+		wipe(e.display[len(e.display):cap(e.display)])
+		if e.onChange != nil {
+			e.onChange("")
+		}
+		return
+	}
+	text := string(e.display)
+	if e.target != nil {
+		*e.target = text
+	}
+	if e.onChange != nil {
+		e.onChange(text)
+	}
+}
+
+// OnChange sets the function that is called with the text whenever the user
+// changes it.  The function is called from queue context so be careful to not
+// use blocking calls.
+// OnChange shall be called from queue context.
+func (e *Edit) OnChange(f func(string)) {
+	e.onChange = f
+}
+
+// OnSubmit sets the function that is called with the text when the user is
+// done, by default on Enter.  If the function returns true the key is
+// consumed, otherwise it is forwarded to the application.  The function is
+// called from queue context so be careful to not use blocking calls.
+// OnSubmit shall be called from queue context.
+func (e *Edit) OnSubmit(f func(string) bool) {
+	e.onSubmit = f
+}
+
+// OnCancel sets the function that is called when the user abandons editing,
+// by default on Esc.  The key is consumed if a function is set.  The function
+// is called from queue context so be careful to not use blocking calls.
+// OnCancel shall be called from queue context.
+func (e *Edit) OnCancel(f func()) {
+	e.onCancel = f
+}
+
+// SetText points the edit at s and sets the edit text to its contents.  s is
+// updated whenever the text changes.  If end is set to true the cursor and
+// text will be set to the end of the string.  This will not be displayed
+// immediately.  SetText is kept for compatibility, use SetValue and OnChange
+// instead.
 // SetText shall be called from queue context.
 func (e *Edit) SetText(s *string, end bool) {
 	e.target = s
-	e.display = []rune(*s)
+	e.SetValue(*s, end)
+}
+
+// SetValue sets the edit text.  If end is set to true the cursor and text will
+// be set to the end of the string.  OnChange is not called and the changes
+// that can be undone are forgotten.  This will not be displayed immediately.
+// SetValue shall be called from queue context.
+func (e *Edit) SetValue(text string, end bool) {
+	e.undo = nil
+	e.redo = nil
+	e.typing = false
+	e.setValue(text, end)
+}
+
+// setValue sets the edit text without forgetting the changes that can be
+// undone.
+// setValue shall be called from queue context.
+func (e *Edit) setValue(text string, end bool) {
+	e.searching = false
+	if e.masked {
+		wipe(e.display[:cap(e.display)])
+	}
+	e.display = []rune(text)
 	e.at = 0
+	if e.target != nil && !e.masked {
+		*e.target = text
+	}
 
 	// send synthesized key to position cursor and text
-	ev := termbox.Event{}
+	e.cy = e.trueY
+	ev := Key{}
 	if end {
-		ev.Key = termbox.KeyCtrlE
+		ev.Action = ActionEditEnd
 	} else {
-		ev.Key = termbox.KeyCtrlA
+		ev.Action = ActionEditHome
 	}
 	e.KeyHandler(ev)
 }
 
+// Resize implements the interface.  The edit is placed according to the
+// window layout or its anchor point and width.  The cursor keeps its position
+// in the text.  This is called from queue context so be careful to not use
+// blocking calls.
 func (e *Edit) Resize() {
-	inString := e.cx - e.trueX + e.at
-	e.trueX = e.x
-	e.trueY = e.y
-	e.trueW = e.width
-
-	// y<0 is relative to bottom line
-	if e.y < 0 {
-		e.trueY = e.w.y + e.y + 1
+	r := e.Bounds(e.width, 1)
+	if e.cx < 0 || e.cy < 0 {
+		// cursor not placed yet
+		e.trueX = r.X
+		e.trueY = r.Y
+		e.trueW = r.Width
+		return
 	}
 
-	// e.width <1 means -width from right hand side
-	if e.width < 1 {
-		e.trueW = e.w.x - e.x + e.width
-	}
+	off := e.cx - e.trueX // cursor offset within the edit
+	inString := off + e.at
+	e.trueX = r.X
+	e.trueY = r.Y
+	e.trueW = r.Width
 
 	// reset cursor and at
-	if e.w.y != e.prevY {
-		e.cy = e.trueY
-		e.prevY = e.w.y
-	}
-	if e.w.x != e.prevX {
-		switch {
-		case len(e.display) == inString:
-			// end of text
-			if len(e.display) < e.trueW-1 {
-				e.cx = e.trueX + len(e.display)
-				e.at = 0
-			} else {
-				e.cx = e.trueX + e.trueW - 1
-				e.at = len(e.display) - e.trueW + 1
-			}
-		case inString <= 0:
-			// begin of text
+	switch {
+	case len(e.display) == inString:
+		// end of text
+		if len(e.display) < e.trueW-1 {
+			off = len(e.display)
 			e.at = 0
-			e.cx = e.trueX
-		default:
-			// middle of text
-			if e.prevX <= e.w.x {
-				// do nothing since x grew
-			} else {
-				// shift location of at based on shrinkage
-				if e.cx >= e.w.x {
-					e.cx -= e.prevX - e.w.x
-					e.at += e.prevX - e.w.x
-				}
-			}
+		} else {
+			off = e.trueW - 1
+			e.at = len(e.display) - e.trueW + 1
 		}
-		e.prevX = e.w.x
+	case inString <= 0:
+		// begin of text
+		e.at = 0
+		off = 0
+	case off > e.trueW-1:
+		// middle of text, shift location of at based on shrinkage
+		e.at += off - (e.trueW - 1)
+		off = e.trueW - 1
 	}
+	e.cx = e.trueX + off
+	e.cy = e.trueY
 }
 
-// AddEdit is a convenience function to add a new edit to a window.  Capacity
-// and width determine the maxima of the returned value.  It wraps the
-// AddWidget call.  AddEdit must be called from queue.
-func (w *Window) AddEdit(x, y, width int, target *string) *Edit {
+// MinSize implements the MinSizer interface.  An edit requires at least one
+// cell.
+func (e *Edit) MinSize() (int, int) {
+	return e.MinBounds(e.width, 1, 1, 1)
+}
+
+// AddEditText is a convenience function to add a new edit with the provided
+// text to a window.  It wraps the AddWidget call.  AddEditText must be called
+// from queue.
+func (w *Window) AddEditText(x, y, width int, text string) *Edit {
 	// we can ignore error for builtins
 	e, _ := w.AddWidget(WidgetEdit, x, y)
 	edit := e.(*Edit)
 	edit.width = width
 
-	// save current sizes to detect actual window resizes
-	edit.prevX = w.x
-	edit.prevY = w.y
-
 	edit.Resize()
 
 	// cursor
 	edit.cx = -1
 	edit.cy = -1
 
-	// set target string
-	edit.SetText(target, true)
+	edit.SetValue(text, true)
 
-	// flip attributes
-	a := defaultAttributes()
-	a2 := Attributes{
-		Fg: a.Bg,
-		Bg: a.Fg,
-	}
-	edit.SetAttributes(a2)
+	// flip colors
+	edit.SetStyle(defaultStyle().Reverse())
+
+	return edit
+}
 
+// AddEdit is like AddEditText but keeps target up to date with the text.  It
+// is kept for compatibility, use AddEditText and OnChange instead.  AddEdit
+// must be called from queue.
+func (w *Window) AddEdit(x, y, width int, target *string) *Edit {
+	edit := w.AddEditText(x, y, width, *target)
+	edit.target = target
 	return edit
 }
+
+// SetHistory attaches a history to the edit.  Submitted lines are added to
+// it, Up and Down recall older and newer lines and Ctrl-R starts a reverse
+// incremental search.  The text that was typed before recalling is kept and
+// returns when moving past the newest line.  A nil history detaches it.
+// SetHistory shall be called from queue context.
+func (e *Edit) SetHistory(h *History) {
+	e.history = h
+	e.histIdx = -1
+	e.draft = ""
+	e.searching = false
+}
+
+// History returns the attached history or nil if there is none.
+// History shall be called from queue context.
+func (e *Edit) History() *History {
+	return e.history
+}
+
+// recall replaces the text as if the user typed it.
+// recall shall be called from queue context.
+func (e *Edit) recall(text string) {
+	e.saveUndo(false)
+	e.setValue(text, true)
+	e.Render()
+	e.changed()
+}
+
+// historyStep recalls the previous line if dir is negative, otherwise the
+// next line.  Moving past the newest line returns to the draft.
+// historyStep shall be called from queue context.
+func (e *Edit) historyStep(dir int) bool {
+	if e.history == nil || e.masked {
+		return false
+	}
+
+	n := e.history.Len()
+	i := e.histIdx
+	if i < 0 {
+		i = n
+	}
+	i += dir
+	if i < 0 || i > n {
+		return true // nothing to recall
+	}
+
+	if e.histIdx < 0 {
+		e.draft = string(e.display)
+	}
+	if i == n {
+		e.histIdx = -1
+		e.recall(e.draft)
+		return true
+	}
+	e.histIdx = i
+	e.recall(e.history.line(i))
+	return true
+}
+
+// startSearch starts a reverse incremental search.
+// startSearch shall be called from queue context.
+func (e *Edit) startSearch() bool {
+	if e.history == nil || e.masked {
+		return false
+	}
+	e.searching = true
+	e.saved = string(e.display)
+	e.query = nil
+	e.match = -1
+	e.failed = false
+	e.Render()
+	return true
+}
+
+// find searches for the query starting at line from and going back.  The
+// previous match is kept if there is none.
+// find shall be called from queue context.
+func (e *Edit) find(from int) {
+	if len(e.query) == 0 {
+		e.match = -1
+		e.failed = false
+		return
+	}
+	i := e.history.search(string(e.query), from)
+	if i < 0 {
+		e.failed = true
+		return
+	}
+	e.match = i
+	e.failed = false
+}
+
+// endSearch stops searching.  If accept is set the text of the match becomes
+// the edit text, otherwise the text from before the search is restored.
+// endSearch shall be called from queue context.
+func (e *Edit) endSearch(accept bool) {
+	e.searching = false
+	if !accept || e.match < 0 {
+		e.setValue(e.saved, true)
+		e.Render()
+		return
+	}
+	if e.histIdx < 0 {
+		e.draft = e.saved
+	}
+	e.histIdx = e.match
+	e.recall(e.history.line(e.match))
+}
+
+// searchKey handles keys while searching.  Ctrl-R finds the next older match,
+// Enter accepts the match and Esc or Ctrl-G restore the text.  Other keys
+// accept the match and are then handled as usual.
+// searchKey shall be called from queue context.
+func (e *Edit) searchKey(ev Key) bool {
+	if ev.Key == KeySpace {
+		// use space
+		ev.Ch = ' '
+	}
+
+	switch {
+	case ev.Action == ActionEditSearch:
+		from := e.history.Len() - 1
+		if e.match >= 0 {
+			from = e.match - 1
+		}
+		e.find(from)
+	case ev.Action == ActionEditBackspace:
+		if len(e.query) > 0 {
+			e.query = e.query[:len(e.query)-1]
+		}
+		e.find(e.history.Len() - 1)
+	case ev.Action == ActionEditCancel || ev.Key == KeyCtrlG:
+		e.endSearch(false)
+		return true
+	case ev.Action == ActionEditSubmit:
+		e.endSearch(true)
+		return true
+	case ev.Ch != 0 && ev.Mod == 0:
+		e.query = append(e.query, ev.Ch)
+		from := e.history.Len() - 1
+		if e.match >= 0 {
+			from = e.match
+		}
+		e.find(from)
+	default:
+		e.endSearch(true)
+		return e.KeyHandler(ev)
+	}
+
+	e.Render()
+	return true
+}
+
+// prompt returns the prompt that is displayed while searching.
+func (e *Edit) prompt() string {
+	if e.failed {
+		return searchFailedPrompt + string(e.query)
+	}
+	return searchPrompt + string(e.query)
+}
+
+// renderSearch displays the search prompt and the matching line.
+// renderSearch shall be called from queue context.
+func (e *Edit) renderSearch() {
+	text := e.prompt() + "': "
+	if e.match >= 0 {
+		text += e.history.line(e.match)
+	}
+	n := e.w.print(e.trueX, e.trueY, e.trueW, e.style, text)
+	e.w.print(e.trueX+n, e.trueY, e.trueW-n, e.style,
+		strings.Repeat(" ", e.trueW-n))
+	e.setSearchCursor()
+}
+
+// setSearchCursor places the cursor behind the query.
+// setSearchCursor shall be called from queue context.
+func (e *Edit) setSearchCursor() {
+	x := len([]rune(e.prompt()))
+	if x > e.trueW-1 {
+		x = e.trueW - 1
+	}
+	e.w.setCursor(e.trueX+x, e.trueY)
+	e.w.setCursorShape(e.insertC)
+}
@@ -0,0 +1,8 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+// Lines exports lines to the tests of package ttk_test.
+var Lines = lines
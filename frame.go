@@ -0,0 +1,261 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+// WidgetFrame uniquely identifies the frame widget.
+const (
+	WidgetFrame = "frame"
+)
+
+var (
+	_ Widgeter = (*Frame)(nil) // ensure interface is satisfied
+)
+
+// Additional borders for Box and Frame.
+var (
+	BorderDouble  = Border{'═', '║', '╔', '╗', '╚', '╝'}
+	BorderRounded = Border{'─', '│', '╭', '╮', '╰', '╯'}
+	BorderASCII   = Border{'-', '|', '+', '+', '+', '+'}
+)
+
+// init registers the Frame Widget.
+func init() {
+	registeredWidgets[WidgetFrame] = NewFrame
+}
+
+// Frame is a container widget that draws a border, with an optional title and
+// footer, around the widgets of its layout.  The area inside the border is
+// assigned to the layout.  The border is highlighted while one of the
+// widgets inside has focus.
+type Frame struct {
+	Widget
+	r          Rect    // area including border
+	width      int     // prefered width
+	height     int     // prefered height
+	border     Border  // border runes
+	content    *Layout // widgets inside the border
+	title      string
+	titleJ     Justify
+	footer     string
+	footerJ    Justify
+	style      Style
+	focusStyle Style
+	visibility Visibility
+}
+
+// NewFrame is the Frame initializer.  This call implements the NewWidget
+// convention by taking a *Window and and an anchor point to render the widget.
+func NewFrame(w *Window, x, y int) (Widgeter, error) {
+	return &Frame{
+		Widget: MakeWidget(w, x, y),
+		border: BorderSingle,
+	}, nil
+}
+
+// AddFrame is a convenience function to add a new frame to a window.  Width
+// and height follow the same rules as they do for AddList.  It wraps the
+// AddWidget call.  AddFrame must be called from queue.
+func (w *Window) AddFrame(x, y, width, height int, b Border) *Frame {
+	// we can ignore error for builtins
+	widget, _ := w.AddWidget(WidgetFrame, x, y)
+	f := widget.(*Frame)
+	f.width = width
+	f.height = height
+	f.border = b
+	f.style = defaultStyle()
+	f.focusStyle = defaultStyle()
+	f.focusStyle.Flags |= StyleBold
+	f.Resize()
+	return f
+}
+
+// SetLayout assigns the area inside the border to the layout.  Use
+// NewLayoutWidget for a single widget.  The widgets are resized immediately.
+// SetLayout shall be called from queue context.
+func (f *Frame) SetLayout(l *Layout) {
+	f.content = l
+	f.place()
+	for _, widget := range l.widgets() {
+		widget.Resize()
+	}
+}
+
+// SetTitle sets the text that is displayed in the top border.  This will not
+// be displayed immediately.
+// SetTitle shall be called from queue context.
+func (f *Frame) SetTitle(text string, j Justify) {
+	f.title = text
+	f.titleJ = j
+}
+
+// SetFooter sets the text that is displayed in the bottom border.  This will
+// not be displayed immediately.
+// SetFooter shall be called from queue context.
+func (f *Frame) SetFooter(text string, j Justify) {
+	f.footer = text
+	f.footerJ = j
+}
+
+// SetStyle sets the border style.  This will not be displayed immediately.
+// SetStyle shall be called from queue context.
+func (f *Frame) SetStyle(s Style) {
+	f.style = s
+}
+
+// SetFocusStyle sets the border style that is used while a widget inside the
+// frame has focus.  This will not be displayed immediately.
+// SetFocusStyle shall be called from queue context.
+func (f *Frame) SetFocusStyle(s Style) {
+	f.focusStyle = s
+}
+
+// Inner returns the area inside the border in window coordinates.
+// Inner shall be called from queue context.
+func (f *Frame) Inner() Rect {
+	r := Rect{
+		X:      f.r.X + 1,
+		Y:      f.r.Y + 1,
+		Width:  f.r.Width - 2,
+		Height: f.r.Height - 2,
+	}
+	if r.Width < 0 {
+		r.Width = 0
+	}
+	if r.Height < 0 {
+		r.Height = 0
+	}
+	return r
+}
+
+// hasFocus returns true if a widget inside the frame has focus.
+func (f *Frame) hasFocus() bool {
+	if f.content == nil {
+		return false
+	}
+	focused := f.w.focusedWidget()
+	for _, widget := range f.content.widgets() {
+		if widget == focused {
+			return true
+		}
+	}
+	return false
+}
+
+// focusChanged implements the focusWatcher interface.
+func (f *Frame) focusChanged() {
+	f.Render()
+}
+
+// MinSize implements the MinSizer interface.  A frame requires room for its
+// border and the minimum size of its layout.
+func (f *Frame) MinSize() (int, int) {
+	width, height := 2, 2
+	if f.content != nil {
+		cw, ch := f.content.minSize()
+		width += cw
+		height += ch
+	}
+	return f.MinBounds(f.width, f.height, width, height)
+}
+
+// place implements the container interface.  The frame is placed and the
+// area inside the border is assigned to the widgets of its layout, nested
+// containers are placed again.
+// place shall be called from queue context.
+func (f *Frame) place() {
+	f.r = f.Bounds(f.width, f.height)
+	if f.content == nil {
+		return
+	}
+	in := f.Inner()
+	f.content.layout(in.X, in.Y, in.Width, in.Height)
+	for _, widget := range f.content.widgets() {
+		if c, ok := widget.(container); ok {
+			c.place()
+		}
+	}
+}
+
+// Resize implements the interface.  The frame is placed, the widgets inside
+// are resized by the window after all containers are placed.  This is called
+// from queue context so be careful to not use blocking calls.
+func (f *Frame) Resize() {
+	f.place()
+}
+
+// Render implements the Render interface.  Only the border is rendered, the
+// widgets inside render themselves.  This is called from queue context so be
+// careful to not use blocking calls.
+func (f *Frame) Render() {
+	c := f.w.Canvas().Clip(f.r)
+	if f.visibility == VisibilityHide {
+		c.Clear(defaultStyle())
+		return
+	}
+
+	s := f.style
+	if f.hasFocus() {
+		s = f.focusStyle
+	}
+	c.Box(Rect{Width: f.r.Width, Height: f.r.Height}, f.border, s)
+	f.caption(c, 0, f.title, f.titleJ, s)
+	f.caption(c, f.r.Height-1, f.footer, f.footerJ, s)
+}
+
+// caption prints text, padded with a space on either side, justified on line
+// y of the border.
+func (f *Frame) caption(c Canvas, y int, text string, j Justify, s Style) {
+	if text == "" || f.r.Height < 1 {
+		return
+	}
+	text = " " + text + " "
+	avail := f.r.Width - 2
+	length := len([]rune(text)) - EscapedLen(text)
+	if length > avail {
+		length = avail
+	}
+
+	x := 1
+	switch j {
+	case JustifyRight:
+		x = f.r.Width - 1 - length
+	case JustifyCenter:
+		x = 1 + (avail-length)/2
+	}
+	c.Clip(Rect{X: x, Y: y, Width: length, Height: 1}).Print(0, 0, s,
+		"%v", text)
+}
+
+// KeyHandler implements the interface.  This is called from queue context
+// so be careful to not use blocking calls.
+func (f *Frame) KeyHandler(ev Key) bool {
+	return false // not handled
+}
+
+// CanFocus implements the interface.  This is called from queue context
+// so be careful to not use blocking calls.
+func (f *Frame) CanFocus() bool {
+	return false // can not be focused
+}
+
+// Focus implements the interface.  This is called from queue context
+// so be careful to not use blocking calls.
+func (f *Frame) Focus() {
+	// do nothing
+}
+
+// Visibility implements the interface.  Hiding the frame does not hide the
+// widgets inside.
+func (f *Frame) Visibility(op Visibility) Visibility {
+	if op != VisibilityGet {
+		f.visibility = op
+	}
+	return f.visibility
+}
+
+// position returns the window coordinates of the widget.
+func (f *Frame) position() (int, int) {
+	return f.r.X, f.r.Y
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// History is a list of submitted lines, oldest first, that can be attached to
+// one or more edits with SetHistory.  Lines are deduplicated, submitting a
+// line again moves it to the end.  History is safe for concurrent use so that
+// it can be loaded and saved outside of queue context.
+type History struct {
+	mtx   sync.Mutex
+	lines []string
+	max   int // maximum number of lines, 0 means unlimited
+}
+
+// NewHistory returns an empty history that holds at most max lines.  The
+// oldest lines are dropped once it is full.  A max of 0 means unlimited.
+func NewHistory(max int) *History {
+	return &History{max: max}
+}
+
+// Add appends a line.  An identical line that was added before is removed and
+// empty lines are ignored.
+func (h *History) Add(line string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.add(line)
+}
+
+// add appends a line, the mutex must be held.
+func (h *History) add(line string) {
+	if line == "" {
+		return
+	}
+	for i, v := range h.lines {
+		if v == line {
+			h.lines = append(h.lines[:i], h.lines[i+1:]...)
+			break
+		}
+	}
+	h.lines = append(h.lines, line)
+	if h.max > 0 && len(h.lines) > h.max {
+		h.lines = append([]string(nil), h.lines[len(h.lines)-h.max:]...)
+	}
+}
+
+// Lines returns a copy of the lines, oldest first.
+func (h *History) Lines() []string {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return append([]string(nil), h.lines...)
+}
+
+// Len returns the number of lines.
+func (h *History) Len() int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return len(h.lines)
+}
+
+// line returns line i, oldest first.
+func (h *History) line(i int) string {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if i < 0 || i >= len(h.lines) {
+		return ""
+	}
+	return h.lines[i]
+}
+
+// search returns the index of the newest line at or before from that contains
+// query, or -1 if there is none.
+func (h *History) search(query string, from int) int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if from >= len(h.lines) {
+		from = len(h.lines) - 1
+	}
+	for i := from; i >= 0; i-- {
+		if strings.Contains(h.lines[i], query) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Load adds the lines read from r, oldest first, one per line.
+func (h *History) Load(r io.Reader) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		h.add(s.Text())
+	}
+	return s.Err()
+}
+
+// Save writes the lines to w, oldest first, one per line.
+func (h *History) Save(w io.Writer) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	bw := bufio.NewWriter(w)
+	for _, line := range h.lines {
+		if _, err := bw.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadFile adds the lines of a file.  See Load for the format.
+func (h *History) LoadFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return h.Load(f)
+}
+
+// SaveFile writes the lines to a file, which is only readable by the user
+// since it may contain sensitive input.  See Save for the format.
+func (h *History) SaveFile(filename string) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = h.Save(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
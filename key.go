@@ -4,13 +4,367 @@
 
 package ttk
 
-import "github.com/gdamore/tcell/termbox"
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell"
+	"github.com/gdamore/tcell/termbox"
+)
+
+// Modifier is a set of modifier keys that were held during a key stroke.
+type Modifier uint8
+
+// Modifier keys.  Control characters such as KeyCtrlA imply the control key
+// and therefore do not carry ModCtrl.
+const (
+	ModAlt Modifier = 1 << iota
+	ModCtrl
+	ModShift
+)
+
+// KeyCode identifies a special key.  Normal keys are reported with a zero
+// KeyCode and the rune in Key.Ch.
+type KeyCode uint16
+
+// Control keys.  These share their values with the ASCII control characters.
+const (
+	KeyCtrlSpace      KeyCode = 0x00
+	KeyCtrlA          KeyCode = 0x01
+	KeyCtrlB          KeyCode = 0x02
+	KeyCtrlC          KeyCode = 0x03
+	KeyCtrlD          KeyCode = 0x04
+	KeyCtrlE          KeyCode = 0x05
+	KeyCtrlF          KeyCode = 0x06
+	KeyCtrlG          KeyCode = 0x07
+	KeyBackspace      KeyCode = 0x08
+	KeyCtrlH          KeyCode = 0x08
+	KeyTab            KeyCode = 0x09
+	KeyCtrlI          KeyCode = 0x09
+	KeyCtrlJ          KeyCode = 0x0a
+	KeyCtrlK          KeyCode = 0x0b
+	KeyCtrlL          KeyCode = 0x0c
+	KeyEnter          KeyCode = 0x0d
+	KeyCtrlM          KeyCode = 0x0d
+	KeyCtrlN          KeyCode = 0x0e
+	KeyCtrlO          KeyCode = 0x0f
+	KeyCtrlP          KeyCode = 0x10
+	KeyCtrlQ          KeyCode = 0x11
+	KeyCtrlR          KeyCode = 0x12
+	KeyCtrlS          KeyCode = 0x13
+	KeyCtrlT          KeyCode = 0x14
+	KeyCtrlU          KeyCode = 0x15
+	KeyCtrlV          KeyCode = 0x16
+	KeyCtrlW          KeyCode = 0x17
+	KeyCtrlX          KeyCode = 0x18
+	KeyCtrlY          KeyCode = 0x19
+	KeyCtrlZ          KeyCode = 0x1a
+	KeyEsc            KeyCode = 0x1b
+	KeyCtrlLsqBracket KeyCode = 0x1b
+	KeyCtrlBackslash  KeyCode = 0x1c
+	KeyCtrlRsqBracket KeyCode = 0x1d
+	KeyCtrlCarat      KeyCode = 0x1e
+	KeyCtrlUnderscore KeyCode = 0x1f
+	KeySpace          KeyCode = 0x20
+	KeyBackspace2     KeyCode = 0x7f
+)
+
+// Special keys.
+const (
+	KeyF1 KeyCode = 0xffff - iota
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyInsert
+	KeyDelete
+	KeyHome
+	KeyEnd
+	KeyPgup
+	KeyPgdn
+	KeyArrowUp
+	KeyArrowDown
+	KeyArrowLeft
+	KeyArrowRight
+	KeyBacktab
+)
 
 // Key contains a key stroke and possible modifiers.
 type Key struct {
-	Mod    termbox.Modifier // key modifier
-	Key    termbox.Key      // special key
-	Ch     rune             // normal key
-	Window Windower         // window that contains widget
-	Widget Widgeter         // widget that emmitted key
+	Mod    Modifier // key modifier
+	Key    KeyCode  // special key
+	Ch     rune     // normal key
+	Window Windower // window that contains widget
+	Widget Widgeter // widget that emmitted key
+	Action string   // action the key is bound to, empty if not bound
+}
+
+// termboxKeys maps the termbox special keys onto ttk key codes.
+var termboxKeys = map[termbox.Key]KeyCode{
+	termbox.KeyF1:                 KeyF1,
+	termbox.KeyF2:                 KeyF2,
+	termbox.KeyF3:                 KeyF3,
+	termbox.KeyF4:                 KeyF4,
+	termbox.KeyF5:                 KeyF5,
+	termbox.KeyF6:                 KeyF6,
+	termbox.KeyF7:                 KeyF7,
+	termbox.KeyF8:                 KeyF8,
+	termbox.KeyF9:                 KeyF9,
+	termbox.KeyF10:                KeyF10,
+	termbox.KeyF11:                KeyF11,
+	termbox.KeyF12:                KeyF12,
+	termbox.KeyInsert:             KeyInsert,
+	termbox.KeyDelete:             KeyDelete,
+	termbox.KeyHome:               KeyHome,
+	termbox.KeyEnd:                KeyEnd,
+	termbox.KeyPgup:               KeyPgup,
+	termbox.KeyPgdn:               KeyPgdn,
+	termbox.KeyArrowUp:            KeyArrowUp,
+	termbox.KeyArrowDown:          KeyArrowDown,
+	termbox.KeyArrowLeft:          KeyArrowLeft,
+	termbox.KeyArrowRight:         KeyArrowRight,
+	termbox.Key(tcell.KeyBacktab): KeyBacktab,
+}
+
+// KeyFromTermbox converts a termbox key event to a Key.  It is provided to
+// ease migrating applications and widgets off termbox.
+func KeyFromTermbox(ev termbox.Event) Key {
+	k := Key{
+		Ch: ev.Ch,
+	}
+
+	m := tcell.ModMask(ev.Mod)
+	if m&tcell.ModAlt != 0 {
+		k.Mod |= ModAlt
+	}
+	if m&tcell.ModCtrl != 0 {
+		k.Mod |= ModCtrl
+	}
+	if m&tcell.ModShift != 0 {
+		k.Mod |= ModShift
+	}
+
+	switch {
+	case ev.Key == termbox.Key(tcell.KeyRune):
+		// normal key, Ch is all we need
+	case ev.Key < 0x80:
+		// control characters imply the control key
+		k.Key = KeyCode(ev.Key)
+		k.Mod &^= ModCtrl
+	default:
+		k.Key = termboxKeys[ev.Key]
+	}
+
+	return k
+}
+
+// Termbox returns the termbox key event that corresponds to k.
+func (k Key) Termbox() termbox.Event {
+	ev := termbox.Event{
+		Type: termbox.EventKey,
+		Ch:   k.Ch,
+	}
+
+	var m tcell.ModMask
+	if k.Mod&ModAlt != 0 {
+		m |= tcell.ModAlt
+	}
+	if k.Mod&ModCtrl != 0 {
+		m |= tcell.ModCtrl
+	}
+	if k.Mod&ModShift != 0 {
+		m |= tcell.ModShift
+	}
+	ev.Mod = termbox.Modifier(m)
+
+	switch {
+	case k.Key == 0 && k.Ch != 0:
+		ev.Key = termbox.Key(tcell.KeyRune)
+	case k.Key < 0x80:
+		ev.Key = termbox.Key(k.Key)
+	default:
+		for tk, v := range termboxKeys {
+			if v == k.Key {
+				ev.Key = tk
+				break
+			}
+		}
+	}
+
+	return ev
+}
+
+// keyNames contains the names of special keys in key notation.  Names are
+// matched case insensitively.
+var keyNames = []struct {
+	name string
+	key  KeyCode
+}{
+	{"F1", KeyF1},
+	{"F2", KeyF2},
+	{"F3", KeyF3},
+	{"F4", KeyF4},
+	{"F5", KeyF5},
+	{"F6", KeyF6},
+	{"F7", KeyF7},
+	{"F8", KeyF8},
+	{"F9", KeyF9},
+	{"F10", KeyF10},
+	{"F11", KeyF11},
+	{"F12", KeyF12},
+	{"Insert", KeyInsert},
+	{"Delete", KeyDelete},
+	{"Home", KeyHome},
+	{"End", KeyEnd},
+	{"PgUp", KeyPgup},
+	{"PgDn", KeyPgdn},
+	{"Up", KeyArrowUp},
+	{"Down", KeyArrowDown},
+	{"Left", KeyArrowLeft},
+	{"Right", KeyArrowRight},
+	{"Backtab", KeyBacktab},
+	{"Tab", KeyTab},
+	{"Enter", KeyEnter},
+	{"Esc", KeyEsc},
+	{"Space", KeySpace},
+	{"Backspace", KeyBackspace2},
+}
+
+// ctrlKeys contains the characters that, together with the control key,
+// produce the control characters that are not letters.
+var ctrlKeys = []struct {
+	ch  rune
+	key KeyCode
+}{
+	{' ', KeyCtrlSpace},
+	{'[', KeyCtrlLsqBracket},
+	{'\\', KeyCtrlBackslash},
+	{']', KeyCtrlRsqBracket},
+	{'^', KeyCtrlCarat},
+	{'_', KeyCtrlUnderscore},
+}
+
+// ParseKey converts a key in key notation to a Key.  Key notation is zero or
+// more modifiers (Ctrl, Alt and Shift) followed by a key name or a single
+// character, separated by dashes, i.e. "Ctrl-Alt-Left", "Alt-x" or "F1".
+// Names and modifiers are case insensitive.  Ctrl with a letter or one of
+// "[\]^_" and Space yields the corresponding control key, other characters
+// keep the Ctrl modifier.  Keys that are sent as Esc followed by the key,
+// which termbox reports with the Alt modifier, are written as Alt, i.e.
+// "Alt-x".
+func ParseKey(s string) (Key, error) {
+	var k Key
+	name := s
+	for {
+		i := strings.IndexByte(name, '-')
+		if i < 1 || i == len(name)-1 {
+			break
+		}
+		switch strings.ToLower(name[:i]) {
+		case "ctrl":
+			k.Mod |= ModCtrl
+		case "alt":
+			k.Mod |= ModAlt
+		case "shift":
+			k.Mod |= ModShift
+		default:
+			return Key{}, fmt.Errorf("invalid modifier in key: %q", s)
+		}
+		name = name[i+1:]
+	}
+
+	for _, v := range keyNames {
+		if !strings.EqualFold(v.name, name) {
+			continue
+		}
+		k.Key = v.key
+		if v.key == KeySpace {
+			if k.Mod&ModCtrl != 0 {
+				k.Key = KeyCtrlSpace
+				k.Mod &^= ModCtrl
+			} else {
+				k.Ch = ' '
+			}
+		}
+		return k, nil
+	}
+
+	r, size := utf8.DecodeRuneInString(name)
+	if r == utf8.RuneError || size != len(name) {
+		return Key{}, fmt.Errorf("invalid key: %q", s)
+	}
+	if k.Mod&ModCtrl == 0 {
+		k.Ch = r
+		return k, nil
+	}
+
+	// control characters imply the control key
+	if lr := unicode.ToLower(r); lr >= 'a' && lr <= 'z' {
+		k.Key = KeyCtrlA + KeyCode(lr-'a')
+		k.Mod &^= ModCtrl
+		return k, nil
+	}
+	for _, v := range ctrlKeys {
+		if v.ch == r {
+			k.Key = v.key
+			k.Mod &^= ModCtrl
+			return k, nil
+		}
+	}
+	k.Ch = r
+	return k, nil
+}
+
+// String returns the key in key notation as understood by ParseKey.
+func (k Key) String() string {
+	var s string
+	mod := k.Mod
+	name := ""
+	for _, v := range keyNames {
+		if v.key == k.Key {
+			name = v.name
+			break
+		}
+	}
+	switch {
+	case name != "":
+	case k.Key == 0 && k.Ch != 0:
+		name = string(k.Ch)
+	case k.Key >= KeyCtrlA && k.Key <= KeyCtrlZ:
+		mod |= ModCtrl
+		name = string(rune('A' + k.Key - KeyCtrlA))
+	case k.Key < KeySpace:
+		mod |= ModCtrl
+		for _, v := range ctrlKeys {
+			if v.key == k.Key {
+				name = string(v.ch)
+				if v.ch == ' ' {
+					name = "Space"
+				}
+				break
+			}
+		}
+	default:
+		return fmt.Sprintf("Key(%#x)", uint16(k.Key))
+	}
+
+	if mod&ModCtrl != 0 {
+		s += "Ctrl-"
+	}
+	if mod&ModAlt != 0 {
+		s += "Alt-"
+	}
+	if mod&ModShift != 0 {
+		s += "Shift-"
+	}
+	return s + name
 }
@@ -0,0 +1,152 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/gdamore/tcell"
+	"github.com/gdamore/tcell/termbox"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		key  Key
+		want string // canonical notation, in if empty
+	}{
+		{"Ctrl-Alt-Left", Key{Mod: ModCtrl | ModAlt, Key: KeyArrowLeft}, ""},
+		{"alt-ctrl-left", Key{Mod: ModCtrl | ModAlt, Key: KeyArrowLeft},
+			"Ctrl-Alt-Left"},
+		{"Ctrl-A", Key{Key: KeyCtrlA}, ""},
+		{"ctrl-a", Key{Key: KeyCtrlA}, "Ctrl-A"},
+		{"Ctrl-Alt-X", Key{Mod: ModAlt, Key: KeyCtrlX}, ""},
+		{"Ctrl-I", Key{Key: KeyTab}, "Tab"},
+		{"Ctrl-[", Key{Key: KeyEsc}, "Esc"},
+		{"Ctrl-Space", Key{Key: KeyCtrlSpace}, ""},
+		{"Ctrl-_", Key{Key: KeyCtrlUnderscore}, ""},
+		{"Space", Key{Key: KeySpace, Ch: ' '}, ""},
+		{"Backspace", Key{Key: KeyBackspace2}, ""},
+		{"Ctrl-H", Key{Key: KeyBackspace}, ""},
+		{"Shift-Tab", Key{Mod: ModShift, Key: KeyTab}, ""},
+		{"Backtab", Key{Key: KeyBacktab}, ""},
+		{"F12", Key{Key: KeyF12}, ""},
+		{"x", Key{Ch: 'x'}, ""},
+		{"X", Key{Ch: 'X'}, ""},
+		{"-", Key{Ch: '-'}, ""},
+		{"Alt--", Key{Mod: ModAlt, Ch: '-'}, ""},
+		{"Alt-é", Key{Mod: ModAlt, Ch: 'é'}, ""},
+		{"Ctrl-1", Key{Mod: ModCtrl, Ch: '1'}, ""},
+	}
+	for _, test := range tests {
+		k, err := ParseKey(test.in)
+		if err != nil {
+			t.Fatalf("%q: %v", test.in, err)
+		}
+		if k != test.key {
+			t.Fatalf("%q: got %+v want %+v", test.in, k, test.key)
+		}
+		want := test.want
+		if want == "" {
+			want = test.in
+		}
+		if k.String() != want {
+			t.Fatalf("%q: got %q want %q", test.in, k.String(), want)
+		}
+	}
+
+	for _, s := range []string{"", "Hyper-x", "xy", "Ctrl-"} {
+		if _, err := ParseKey(s); err == nil {
+			t.Fatalf("%q: expected error", s)
+		}
+	}
+}
+
+func TestKeyRoundTrip(t *testing.T) {
+	codes := []KeyCode{KeySpace, KeyBackspace2}
+	for c := KeyCtrlSpace; c <= KeyCtrlUnderscore; c++ {
+		codes = append(codes, c)
+	}
+	for c := KeyBacktab; ; c++ {
+		codes = append(codes, c)
+		if c == KeyF1 {
+			break
+		}
+	}
+	runes := []rune{'a', 'Z', '0', '-', '~', 'é', '世'}
+
+	for mod := Modifier(0); mod <= ModAlt|ModCtrl|ModShift; mod++ {
+		var keys []Key
+		for _, c := range codes {
+			k := Key{Mod: mod, Key: c}
+			if c == KeySpace {
+				k.Ch = ' '
+			}
+			keys = append(keys, k)
+		}
+		for _, r := range runes {
+			if mod&ModCtrl != 0 && unicode.IsLetter(r) && r < 0x80 {
+				// Ctrl with a letter is a control character
+				continue
+			}
+			keys = append(keys, Key{Mod: mod, Ch: r})
+		}
+
+		for _, k := range keys {
+			s := k.String()
+			pk, err := ParseKey(s)
+			if err != nil {
+				t.Fatalf("%+v %q: %v", k, s, err)
+			}
+			if pk.String() != s {
+				t.Fatalf("%+v: %q became %q", k, s, pk.String())
+			}
+
+			// control characters imply Ctrl so it is dropped
+			if k.Mod&ModCtrl != 0 && k.Key < KeySpace &&
+				pk.Mod&ModCtrl == 0 {
+				k.Mod &^= ModCtrl
+			}
+			if k.Mod&ModCtrl != 0 && k.Key == KeySpace {
+				// Ctrl-Space is a control character
+				continue
+			}
+			if pk != k {
+				t.Fatalf("%q: got %+v want %+v", s, pk, k)
+			}
+		}
+	}
+}
+
+func TestKeyAltEscape(t *testing.T) {
+	// termbox.InputAlt reports Esc followed by a key as Alt
+	tests := []struct {
+		ev   termbox.Event
+		want string
+	}{
+		{termbox.Event{Type: termbox.EventKey, Mod: termbox.ModAlt,
+			Key: termbox.Key(tcell.KeyRune), Ch: 'x'}, "Alt-x"},
+		{termbox.Event{Type: termbox.EventKey, Mod: termbox.ModAlt,
+			Key: termbox.KeyCtrlA}, "Ctrl-Alt-A"},
+		{termbox.Event{Type: termbox.EventKey, Mod: termbox.ModAlt,
+			Key: termbox.KeyArrowUp}, "Alt-Up"},
+		{termbox.Event{Type: termbox.EventKey, Mod: termbox.ModAlt,
+			Key: termbox.KeyEsc}, "Alt-Esc"},
+	}
+	for _, test := range tests {
+		k := KeyFromTermbox(test.ev)
+		if k.String() != test.want {
+			t.Fatalf("got %q want %q", k.String(), test.want)
+		}
+		pk, err := ParseKey(test.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pk != k {
+			t.Fatalf("%q: got %+v want %+v", test.want, pk, k)
+		}
+	}
+}
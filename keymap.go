@@ -0,0 +1,440 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Window actions.  They are bound in the global keymap and handled by ttk
+// unless the focused widget uses them.
+const (
+	ActionFocusNext     = "focus.next"     // focus on next widget
+	ActionFocusPrevious = "focus.previous" // focus on previous widget
+)
+
+// ScopeGlobal is the scope name of the global keymap in keymap files.  Widget
+// keymaps use the widget id as scope name, i.e. WidgetEdit.
+const ScopeGlobal = "global"
+
+var (
+	// globalKeymap is consulted after the widget and window keymaps.
+	globalKeymap = NewKeymap()
+
+	// widgetKeymaps contains the keymaps of the registered widget types.
+	widgetKeymaps   = make(map[string]*Keymap)
+	widgetKeymapMtx sync.Mutex
+
+	// actions contains the functions that are called by HandleAction.
+	actions   = make(map[string]func(Key))
+	actionMtx sync.Mutex
+
+	// chord state, only accessed from queue context
+	chord        []Key         // keys of the chord in progress
+	chordGen     int           // invalidates pending chord timeouts
+	chordTimeout = time.Second // time to wait for the next chord key
+
+	// windowActions are the actions that ttk handles on the input window.
+	windowActions = map[string]func(*Window){
+		ActionFocusNext:     (*Window).focusNext,
+		ActionFocusPrevious: (*Window).focusPrevious,
+	}
+)
+
+// init binds the window actions.
+func init() {
+	for _, b := range []struct {
+		keys   string
+		action string
+	}{
+		{"Tab", ActionFocusNext},
+		{"Backtab", ActionFocusPrevious},
+		{"Shift-Tab", ActionFocusPrevious},
+	} {
+		if err := globalKeymap.Bind(b.keys, b.action); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// keyStroke is the part of a Key that identifies what was pressed.
+type keyStroke struct {
+	mod Modifier
+	key KeyCode
+	ch  rune
+}
+
+// keymapNode is a node in the tree of key sequences.
+type keymapNode struct {
+	action string
+	next   map[keyStroke]*keymapNode
+}
+
+// Keymap binds keys and key sequences (chords) to action names.  Keys that
+// are bound are delivered with Key.Action set.  A Keymap may be used from any
+// go routine.
+type Keymap struct {
+	mtx    sync.Mutex
+	root   keymapNode
+	parent *Keymap // consulted if there is no binding
+}
+
+// NewKeymap returns an empty keymap.
+func NewKeymap() *Keymap {
+	return &Keymap{}
+}
+
+// Bind binds the keys, i.e. "Ctrl-X Ctrl-C", to the action.  Keys are in the
+// notation of ParseKey and separated by white space.
+func (km *Keymap) Bind(keys string, action string) error {
+	seq, err := parseKeys(keys)
+	if err != nil {
+		return err
+	}
+	km.BindKeys(action, seq...)
+	return nil
+}
+
+// BindKeys binds the key sequence to the action.  An empty action removes the
+// binding.
+func (km *Keymap) BindKeys(action string, keys ...Key) {
+	if len(keys) == 0 {
+		return
+	}
+
+	km.mtx.Lock()
+	defer km.mtx.Unlock()
+	n := &km.root
+	for _, k := range keys {
+		if n.next == nil {
+			n.next = make(map[keyStroke]*keymapNode)
+		}
+		s := keyStroke{mod: k.Mod, key: k.Key, ch: k.Ch}
+		if n.next[s] == nil {
+			n.next[s] = &keymapNode{}
+		}
+		n = n.next[s]
+	}
+	n.action = action
+}
+
+// Unbind removes the binding of the keys.
+func (km *Keymap) Unbind(keys string) error {
+	return km.Bind(keys, "")
+}
+
+// lookup returns the action that is bound to the keys.  It also returns true
+// if the keys are the beginning of a longer binding.
+func (km *Keymap) lookup(keys []Key) (string, bool) {
+	if km == nil {
+		return "", false
+	}
+
+	km.mtx.Lock()
+	n := &km.root
+	for _, k := range keys {
+		n = n.next[keyStroke{mod: k.Mod, key: k.Key, ch: k.Ch}]
+		if n == nil {
+			break
+		}
+	}
+	var (
+		action string
+		prefix bool
+	)
+	if n != nil {
+		action = n.action
+		for _, v := range n.next {
+			if v.action != "" || len(v.next) > 0 {
+				prefix = true
+				break
+			}
+		}
+	}
+	km.mtx.Unlock()
+
+	if action != "" {
+		return action, prefix
+	}
+	pa, pp := km.parent.lookup(keys)
+	return pa, prefix || pp
+}
+
+// parseKeys converts white space separated keys to a key sequence.
+func parseKeys(keys string) ([]Key, error) {
+	var seq []Key
+	for _, f := range strings.Fields(keys) {
+		k, err := ParseKey(f)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, k)
+	}
+	if len(seq) == 0 {
+		return nil, fmt.Errorf("no keys")
+	}
+	return seq, nil
+}
+
+// GlobalKeymap returns the keymap that applies in all windows.  It is
+// consulted after the keymaps of the focused widget and window.
+func GlobalKeymap() *Keymap {
+	return globalKeymap
+}
+
+// WidgetKeymap returns the keymap of a widget type, i.e. WidgetEdit.  The
+// built-in actions of widgets are bound here and can be rebound by the
+// application.  The keymap of every widget falls back to the keymap of its
+// type.
+func WidgetKeymap(id string) *Keymap {
+	widgetKeymapMtx.Lock()
+	defer widgetKeymapMtx.Unlock()
+	km, found := widgetKeymaps[id]
+	if !found {
+		km = NewKeymap()
+		widgetKeymaps[id] = km
+	}
+	return km
+}
+
+// Keymap returns the keymap of the window.  It is consulted after the keymap
+// of the focused widget and before the global keymap.
+func (w *Window) Keymap() *Keymap {
+	return w.keymap
+}
+
+// Keymap returns the keymap of the widget.  It is consulted first and falls
+// back to the keymap of the widget type.
+func (w *Widget) Keymap() *Keymap {
+	return w.keymap
+}
+
+// SetChordTimeout sets how long to wait for the next key of a chord.  When the
+// time runs out the keys typed so far are delivered without action.
+func SetChordTimeout(d time.Duration) {
+	Queue(func() {
+		chordTimeout = d
+	})
+}
+
+// RegisterAction registers the function that HandleAction calls for the
+// action.
+func RegisterAction(action string, f func(Key)) {
+	actionMtx.Lock()
+	actions[action] = f
+	actionMtx.Unlock()
+}
+
+// HandleAction calls the function that was registered for the action of the
+// key.  It returns false if the key has no action or there is no function
+// registered for it.  HandleAction is meant to be called by the application
+// with the keys that are read from KeyChannel.  It shall not be called from
+// queue context.
+func HandleAction(k Key) bool {
+	if k.Action == "" {
+		return false
+	}
+	actionMtx.Lock()
+	f, found := actions[k.Action]
+	actionMtx.Unlock()
+	if !found {
+		return false
+	}
+	f(k)
+	return true
+}
+
+// LoadKeymap reads bindings from r.  Each line contains a scope, an action and
+// the keys it is bound to, i.e.:
+//
+//	global quit Ctrl-X Ctrl-C
+//	edit edit.home Ctrl-B
+//
+// The scope is either ScopeGlobal or a widget id.  The action "none" removes a
+// binding.  Empty lines and lines starting with # are ignored.
+func LoadKeymap(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		f := strings.Fields(s.Text())
+		if len(f) == 0 || strings.HasPrefix(f[0], "#") {
+			continue
+		}
+		if len(f) < 3 {
+			return fmt.Errorf("line %v: expected scope, action and "+
+				"keys", line)
+		}
+
+		var km *Keymap
+		if f[0] == ScopeGlobal {
+			km = globalKeymap
+		} else if _, found := lookupWidget(f[0]); found {
+			km = WidgetKeymap(f[0])
+		} else {
+			return fmt.Errorf("line %v: invalid scope: %v", line, f[0])
+		}
+
+		action := f[1]
+		if action == "none" {
+			action = ""
+		}
+		err := km.Bind(strings.Join(f[2:], " "), action)
+		if err != nil {
+			return fmt.Errorf("line %v: %v", line, err)
+		}
+	}
+	return s.Err()
+}
+
+// LoadKeymapFile reads bindings from a file.  See LoadKeymap for the format.
+func LoadKeymapFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return LoadKeymap(f)
+}
+
+// keymaps returns the keymaps of the focused widget and window of the input
+// window and the global keymap.  Missing keymaps are nil.
+// keymaps shall be called from queue context.
+func keymaps() []*Keymap {
+	kms := []*Keymap{nil, nil, globalKeymap}
+	iw := inputWindow()
+	if iw == nil {
+		return kms
+	}
+	kms[1] = iw.keymap
+	if b, ok := iw.focusedWidget().(widgetBase); ok {
+		kms[0] = b.base().keymap
+	}
+	return kms
+}
+
+// handleKey looks up the key in the keymaps of the focused widget, the input
+// window and the global keymap, in that order, and dispatches it.  Keys that
+// start a chord are held until the chord completes or times out.  A widget
+// action that the widget does not use falls back to the binding of the window
+// or global keymap, i.e. Tab in an edit without completion moves focus.
+// handleKey shall be called from queue context.
+func handleKey(k Key) {
+	chord = append(chord, k)
+	chordGen++
+
+	pending := false
+	for scope, km := range keymaps() {
+		action, prefix := km.lookup(chord)
+		if action != "" && !prefix && !pending {
+			keys := chord
+			chord = nil
+			k.Action = action
+			dispatchKey(k, keys, scope == 0)
+			return
+		}
+		pending = pending || prefix || action != ""
+	}
+
+	if pending {
+		gen := chordGen
+		time.AfterFunc(chordTimeout, func() {
+			Queue(func() {
+				if gen == chordGen {
+					expireChord()
+				}
+			})
+		})
+		return
+	}
+
+	// not bound, deliver keys as they are
+	keys := chord
+	chord = nil
+	for _, v := range keys {
+		dispatchKey(v, nil, true)
+	}
+}
+
+// expireChord delivers the keys of a chord that did not complete in time.  If
+// the keys typed so far are bound the action is delivered instead.
+// expireChord shall be called from queue context.
+func expireChord() {
+	keys := chord
+	chord = nil
+	if len(keys) == 0 {
+		return
+	}
+
+	// the first scope with a binding wins
+	for scope, km := range keymaps() {
+		action, _ := km.lookup(keys)
+		if action == "" {
+			continue
+		}
+		k := keys[len(keys)-1]
+		k.Action = action
+		dispatchKey(k, keys, scope == 0)
+		return
+	}
+
+	for _, k := range keys {
+		dispatchKey(k, nil, true)
+	}
+}
+
+// outerAction returns the action that the keys are bound to in the window or
+// global keymap.
+// outerAction shall be called from queue context.
+func outerAction(keys []Key) string {
+	for _, km := range keymaps()[1:] {
+		if action, _ := km.lookup(keys); action != "" {
+			return action
+		}
+	}
+	return ""
+}
+
+// dispatchKey sends the key to the focused widget of the input window, if
+// toWidget is set, and to the application if the widget did not use it.  Keys
+// are the keys that were looked up to find the action of k.
+// dispatchKey shall be called from queue context.
+func dispatchKey(k Key, keys []Key, toWidget bool) {
+	if iw := inputWindow(); iw != nil {
+		if toWidget {
+			var used bool
+			used, k.Window, k.Widget = iw.keyHandler(k)
+			if used {
+				flush()
+				return
+			}
+			if action := outerAction(keys); action != "" {
+				k.Action = action
+			}
+		} else {
+			k.Window = iw.mgr
+			k.Widget = iw.focusedWidget()
+		}
+		if f, found := windowActions[k.Action]; found {
+			if !iw.tooSmall {
+				f(iw)
+			}
+			flush()
+			return
+		}
+	}
+
+	// forward to global application handler
+	keyC <- k
+	// XXX this is a terrible workaround!!
+	// the app is racing this channel
+	// we need to somehow block here before doing
+	// anything else
+	//time.Sleep(25 * time.Millisecond)
+}
@@ -7,8 +7,6 @@ package ttk
 import (
 	"fmt"
 	"strings"
-
-	"github.com/gdamore/tcell/termbox"
 )
 
 // WidgetLabel uniquely identifies the label widget.
@@ -31,11 +29,13 @@ type Label struct {
 	Widget
 	trueX int
 	trueY int
+	trueW int
 	text  string
-	attr  Attributes
+	style Style
 
 	// status label only
 	status     bool // status means fill entire line
+	unread     bool // changed since announced in linear mode
 	justify    Justify
 	visibility Visibility
 }
@@ -54,7 +54,7 @@ func (l *Label) Visibility(op Visibility) Visibility {
 }
 
 func (l *Label) clear() {
-	l.w.printf(l.trueX, l.trueY, defaultAttributes(), strings.Repeat(" ", l.w.x))
+	l.w.printf(l.trueX, l.trueY, defaultStyle(), strings.Repeat(" ", l.trueW))
 }
 
 // Render implements the Render interface.  This is called from queue context
@@ -66,12 +66,12 @@ func (l *Label) Render() {
 	}
 
 	if !l.status {
-		l.w.printf(l.trueX, l.trueY, l.attr, "%v", l.text)
+		l.w.print(l.trueX, l.trueY, l.trueW, l.style, l.text)
 		return
 	}
 
 	text := l.text
-	spacing := l.w.x - len([]rune(text)) + EscapedLen(text)
+	spacing := l.trueW - len([]rune(text)) + EscapedLen(text)
 	if spacing < 0 {
 		spacing = 0
 	}
@@ -87,16 +87,25 @@ func (l *Label) Render() {
 		left = strings.Repeat(" ", spacing/2)
 		right = strings.Repeat(" ", spacing/2+spacing%2)
 	}
-	x := 0
-	l.w.printf(x, l.trueY, l.attr, "%v%v%v", left, text, right)
+
+	// print text separately so that padding is not reordered with it
+	x := l.trueX
+	x += l.w.print(x, l.trueY, l.trueW, l.style, left)
+	x += l.w.print(x, l.trueY, l.trueX+l.trueW-x, l.style, text)
+	l.w.print(x, l.trueY, l.trueX+l.trueW-x, l.style, right)
 }
 
 // KeyHandler implements the interface.  This is called from queue context
 // so be careful to not use blocking calls.
-func (l *Label) KeyHandler(ev termbox.Event) bool {
+func (l *Label) KeyHandler(ev Key) bool {
 	return false // not handled
 }
 
+// position returns the window coordinates of the widget.
+func (l *Label) position() (int, int) {
+	return l.trueX, l.trueY
+}
+
 // CanFocus implements the interface.  This is called from queue context
 // so be careful to not use blocking calls.
 func (l *Label) CanFocus() bool {
@@ -117,16 +126,22 @@ func NewLabel(w *Window, x, y int) (Widgeter, error) {
 	}, nil
 }
 
-// SetAttributes sets the Attributes.  This will not be displayed immediately.
-// SetAttributes shall be called from queue context.
-func (l *Label) SetAttributes(a Attributes) {
-	l.attr = a
+// SetStyle sets the Style.  This will not be displayed immediately.
+// SetStyle shall be called from queue context.
+func (l *Label) SetStyle(s Style) {
+	l.style = s
 }
 
 // SetText sets the label caption.  This will not be displayed immediately.
 // SetText shall be called from queue context.
 func (l *Label) SetText(format string, args ...interface{}) {
 	l.text = fmt.Sprintf(format, args...)
+	l.unread = true
+}
+
+// Describe implements the Describer interface.
+func (l *Label) Describe() string {
+	return "label: " + l.text
 }
 
 // AddLabel is a convenience function to add a new label to a window.  It wraps
@@ -136,7 +151,7 @@ func (w *Window) AddLabel(x, y int, format string, args ...interface{}) *Label {
 	l, _ := w.AddWidget(WidgetLabel, x, y)
 	label := l.(*Label)
 	label.Resize()
-	label.SetAttributes(defaultAttributes())
+	label.SetStyle(defaultStyle())
 	label.SetText(format, args...)
 
 	return label
@@ -153,14 +168,20 @@ const (
 	JustifyCenter
 )
 
+// Resize implements the interface.  A label extends to the right edge of the
+// window unless the window layout says otherwise.  This is called from queue
+// context so be careful to not use blocking calls.
 func (l *Label) Resize() {
-	l.trueX = l.x
-	l.trueY = l.y
+	r := l.Bounds(0, 1)
+	l.trueX = r.X
+	l.trueY = r.Y
+	l.trueW = r.Width
+}
 
-	// y<0 means lines from the bottom
-	if l.y < 0 {
-		l.trueY = l.w.y + l.y
-	}
+// MinSize implements the MinSizer interface.  A label requires at least one
+// cell.
+func (l *Label) MinSize() (int, int) {
+	return l.MinBounds(0, 1, 1, 1)
 }
 
 // AddStatus is an alternative Label initializer.  A Status is a label that has
@@ -175,13 +196,8 @@ func (w *Window) AddStatus(y int, j Justify, format string,
 	label.status = true
 	label.justify = j
 
-	// flip attributes
-	a := defaultAttributes()
-	a2 := Attributes{
-		Fg: a.Bg,
-		Bg: a.Fg,
-	}
-	label.SetAttributes(a2)
+	// flip colors
+	label.SetStyle(defaultStyle().Reverse())
 
 	// print
 	label.SetText(format, args...)
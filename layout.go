@@ -0,0 +1,290 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+// Constraint is the size of a Layout within its parent along the direction of
+// the parent split.  Fixed and Percent items are sized first, the remaining
+// space is divided evenly among the items that set neither.  The result is
+// always kept within Min and Max.
+type Constraint struct {
+	Fixed   int // size in cells, 0 if not fixed
+	Percent int // percentage of the parent size, 0 if not a percentage
+	Min     int // minimum size in cells
+	Max     int // maximum size in cells, 0 means no maximum
+}
+
+// min returns the smallest size the item can be given.
+func (c Constraint) min() int {
+	if c.Fixed > 0 {
+		return c.clamp(c.Fixed)
+	}
+	return c.Min
+}
+
+// fill returns true if the item takes a share of the remaining space.
+func (c Constraint) fill() bool {
+	return c.Fixed <= 0 && c.Percent <= 0
+}
+
+// clamp returns size limited to Min and Max.
+func (c Constraint) clamp(size int) int {
+	if c.Max > 0 && size > c.Max {
+		size = c.Max
+	}
+	if size < c.Min {
+		size = c.Min
+	}
+	return size
+}
+
+// Layout is a node in the widget layout of a window.  A leaf layout assigns
+// its area to a widget, other layouts split their area among their children.
+// A leaf without a widget is an empty spacer.
+type Layout struct {
+	Size Constraint // size within the parent
+
+	widget   Widgeter  // leaf only
+	split    Split     // split only
+	children []*Layout // split only
+}
+
+// NewLayoutWidget returns a leaf layout that assigns its area to the provided
+// widget.  A nil widget leaves the area empty.
+func NewLayoutWidget(w Widgeter, size Constraint) *Layout {
+	return &Layout{
+		Size:   size,
+		widget: w,
+	}
+}
+
+// NewLayout returns a layout that divides its area among the children in the
+// provided direction.
+func NewLayout(s Split, size Constraint, children ...*Layout) *Layout {
+	return &Layout{
+		Size:     size,
+		split:    s,
+		children: children,
+	}
+}
+
+// rectSetter is implemented by widgets that embed Widget.
+type rectSetter interface {
+	setRect(Rect)
+	clearRect()
+}
+
+// container is implemented by widgets that place other widgets with a layout,
+// i.e. Frame.  Windows place containers before the minimum sizes are checked
+// and the widgets are resized.
+type container interface {
+	place()
+}
+
+// anchorLayout returns the layout along one axis that places a widget at pos
+// with the provided size, following the anchor conventions of Bounds, and the
+// index of the widget.  The index is -1 if the widget has no size, it is then
+// located behind the first child.  Min is the minimum size of a widget that
+// extends to the edge.
+func anchorLayout(pos, size, min int) (*Layout, int) {
+	l := &Layout{}
+	add := func(c Constraint) {
+		l.children = append(l.children, &Layout{Size: c})
+	}
+
+	if pos >= 0 {
+		if pos > 0 {
+			add(Constraint{Fixed: pos})
+		}
+		i := len(l.children)
+		if size >= 1 {
+			add(Constraint{Fixed: size})
+			add(Constraint{})
+			return l, i
+		}
+		add(Constraint{Min: min})
+		if size < 0 {
+			add(Constraint{Fixed: -size})
+		}
+		return l, i
+	}
+
+	// counted from the end, the widget never extends past the edge
+	n := -pos + size
+	if size >= 1 {
+		n = size
+		if n > -pos {
+			n = -pos
+		}
+	}
+	add(Constraint{})
+	if n <= 0 {
+		add(Constraint{Fixed: -pos})
+		return l, -1
+	}
+	add(Constraint{Fixed: n})
+	if -pos-n > 0 {
+		add(Constraint{Fixed: -pos - n})
+	}
+	return l, 1
+}
+
+// anchorAxis returns the position and size of a widget at pos with the
+// provided size along an axis of total cells.
+func anchorAxis(pos, size, total int) (int, int) {
+	l, i := anchorLayout(pos, size, 0)
+	sizes := l.sizes(total)
+	if i < 0 {
+		return sizes[0], 0
+	}
+	at := 0
+	for _, s := range sizes[:i] {
+		at += s
+	}
+	return at, sizes[i]
+}
+
+// minSize returns the smallest area in which all children of the layout
+// receive at least their minimum size.
+func (l *Layout) minSize() (int, int) {
+	var along, across int
+	for _, c := range l.children {
+		a, b := c.minSize()
+		if l.split == SplitRows {
+			a, b = b, a
+		}
+		if m := c.Size.min(); m > a {
+			a = m
+		}
+		along += a
+		if b > across {
+			across = b
+		}
+	}
+	if l.split == SplitRows {
+		return across, along
+	}
+	return along, across
+}
+
+// sizes divides avail cells among the children.  If the children do not fit
+// the last ones are clipped.
+func (l *Layout) sizes(avail int) []int {
+	sizes := make([]int, len(l.children))
+	done := make([]bool, len(l.children))
+	left := avail
+	fills := 0
+	for i, c := range l.children {
+		switch {
+		case c.Size.Fixed > 0:
+			sizes[i] = c.Size.clamp(c.Size.Fixed)
+		case c.Size.Percent > 0:
+			sizes[i] = c.Size.clamp(avail * c.Size.Percent / 100)
+		default:
+			fills++
+			continue
+		}
+		done[i] = true
+		left -= sizes[i]
+	}
+
+	// divide the remainder evenly, fill items that end up outside their
+	// bounds are pinned and the rest is divided again
+	for fills > 0 {
+		share := 0
+		if left > 0 {
+			share = left / fills
+		}
+		pinned := false
+		for i, c := range l.children {
+			if done[i] || c.Size.clamp(share) == share {
+				continue
+			}
+			sizes[i] = c.Size.clamp(share)
+			done[i] = true
+			left -= sizes[i]
+			fills--
+			pinned = true
+		}
+		if pinned {
+			continue
+		}
+
+		// the last fill item gets the rounding error
+		last := -1
+		for i := range l.children {
+			if done[i] {
+				continue
+			}
+			sizes[i] = share
+			last = i
+		}
+		if last >= 0 && left > share*fills {
+			sizes[last] += left - share*fills
+		}
+		break
+	}
+
+	// clip what does not fit
+	for i := range sizes {
+		if sizes[i] > avail {
+			sizes[i] = avail
+		}
+		avail -= sizes[i]
+	}
+
+	return sizes
+}
+
+// widgets returns all widgets in the layout.
+func (l *Layout) widgets() []Widgeter {
+	if l.children == nil {
+		if l.widget == nil {
+			return nil
+		}
+		return []Widgeter{l.widget}
+	}
+	var ws []Widgeter
+	for _, c := range l.children {
+		ws = append(ws, c.widgets()...)
+	}
+	return ws
+}
+
+// remove turns the leaf that holds the widget into an empty spacer.
+func (l *Layout) remove(w Widgeter) {
+	if l.widget == w {
+		l.widget = nil
+	}
+	for _, c := range l.children {
+		c.remove(w)
+	}
+}
+
+// layout assigns the area to the layout and its children.  Widgets are not
+// resized.
+// layout shall be called from queue context.
+func (l *Layout) layout(x, y, width, height int) {
+	if l.children == nil {
+		if r, ok := l.widget.(rectSetter); ok {
+			r.setRect(Rect{X: x, Y: y, Width: width, Height: height})
+		}
+		return
+	}
+
+	total := width
+	if l.split == SplitRows {
+		total = height
+	}
+	for i, size := range l.sizes(total) {
+		c := l.children[i]
+		if l.split == SplitRows {
+			c.layout(x, y, width, size)
+			y += size
+		} else {
+			c.layout(x, y, size, height)
+			x += size
+		}
+	}
+}
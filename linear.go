@@ -0,0 +1,134 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"fmt"
+	"io"
+)
+
+// ActionReadStatus announces the status labels of the input window in linear
+// mode.  It is not bound by default.
+const ActionReadStatus = "linear.status"
+
+// linearOut receives the text stream in linear mode, nil while ttk paints
+// cells.
+var linearOut io.Writer
+
+// init registers the linear mode window actions.
+func init() {
+	windowActions[ActionReadStatus] = (*Window).readStatus
+}
+
+// Describer is an optional interface that widgets implement to describe
+// themselves when they are focused in linear mode, i.e. "edit: name, contents
+// hello".  Describe is called from queue context so be careful to not use
+// blocking calls.
+type Describer interface {
+	Describe() string
+}
+
+// SetLinear switches to linear mode for screen readers.  In linear mode ttk
+// stops painting cells and writes lines of plain text to out instead: lines
+// that are appended to lists of the input window, focus changes and, on
+// demand, status labels.  Lines end in "\r\n" since the terminal is in raw
+// mode.  With a Terminal out typically is the same connection; with Init it is
+// os.Stdout.  A nil out switches back to painting cells and repaints the
+// screen.
+func SetLinear(out io.Writer) {
+	Queue(func() {
+		linearOut = out
+		if term == nil {
+			return
+		}
+		term.clear(defaultStyle())
+		resetFront()
+		if out != nil {
+			_, _ = term.flush()
+			announceWindow(focus)
+			return
+		}
+		resizeAndRender(focus)
+	})
+}
+
+// Announce writes a line of text in linear mode and does nothing otherwise.
+// Escape sequences are removed.
+func Announce(format string, args ...interface{}) {
+	Queue(func() {
+		announce(format, args...)
+	})
+}
+
+// announce writes a line of text in linear mode.
+// announce shall be called from queue context.
+func announce(format string, args ...interface{}) {
+	if linearOut == nil {
+		return
+	}
+	_, _ = io.WriteString(linearOut,
+		Unescape(fmt.Sprintf(format, args...))+"\r\n")
+}
+
+// announceWindow announces the window title and its focused widget.
+// announceWindow shall be called from queue context.
+func announceWindow(w *Window) {
+	if linearOut == nil || w == nil {
+		return
+	}
+	if w.title != "" {
+		announce("window: %v", w.title)
+	}
+	announceWidget(w.focusedWidget())
+}
+
+// announceWidget announces the widget description.  Widgets that are not a
+// Describer are announced by name.
+// announceWidget shall be called from queue context.
+func announceWidget(widget Widgeter) {
+	if linearOut == nil || widget == nil {
+		return
+	}
+	text := ""
+	if d, ok := widget.(Describer); ok {
+		text = d.Describe()
+	} else if b, ok := widget.(widgetBase); ok {
+		text = b.base().name
+	}
+	if text != "" {
+		announce("%v", text)
+	}
+}
+
+// readStatus announces the status labels that changed since they were last
+// read.  If none changed all of them are announced again.
+// readStatus shall be called from queue context.
+func (w *Window) readStatus() {
+	var status []*Label
+	for _, widget := range w.widgets {
+		l, ok := widget.(*Label)
+		if !ok || !l.status || l.visibility == VisibilityHide {
+			continue
+		}
+		status = append(status, l)
+	}
+
+	unread := false
+	for _, l := range status {
+		unread = unread || l.unread
+	}
+	for _, l := range status {
+		if l.unread || !unread {
+			announce("status: %v", l.text)
+		}
+		l.unread = false
+	}
+}
+
+// ReadStatus announces the status labels that changed since they were last
+// read in linear mode.  If none changed all of them are announced again.
+func (w *Window) ReadStatus() {
+	Queue(w.readStatus)
+}
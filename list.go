@@ -8,8 +8,6 @@ import (
 	"fmt"
 	"strings"
 	"unicode/utf8"
-
-	"github.com/gdamore/tcell/termbox"
 )
 
 // WidgetList uniquely identifies the list widget.
@@ -38,8 +36,9 @@ type List struct {
 	trueY      int
 	at         int  // top line being displayed
 	paging     bool // paging in progress?
-	attr       Attributes
+	style      Style
 	content    []string
+	activity   Activity // window activity raised by Append
 	visibility Visibility
 }
 
@@ -60,9 +59,8 @@ func (l *List) Visibility(op Visibility) Visibility {
 
 func (l *List) clear() {
 	s := strings.Repeat(" ", l.trueW)
-	x := 0
 	for i := 0; i < l.trueH; i++ {
-		l.w.printf(x, i+l.trueY, defaultAttributes(), s)
+		l.w.printf(l.trueX, i+l.trueY, defaultStyle(), s)
 	}
 }
 
@@ -78,10 +76,15 @@ func (l *List) Render() {
 
 // KeyHandler implements the interface.  This is called from queue context
 // so be careful to not use blocking calls.
-func (l *List) KeyHandler(ev termbox.Event) bool {
+func (l *List) KeyHandler(ev Key) bool {
 	return false // not handled
 }
 
+// position returns the window coordinates of the widget.
+func (l *List) position() (int, int) {
+	return l.trueX, l.trueY
+}
+
 // CanFocus implements the interface.  This is called from queue context
 // so be careful to not use blocking calls.
 func (l *List) CanFocus() bool {
@@ -102,27 +105,21 @@ func NewList(w *Window, x, y int) (Widgeter, error) {
 	}, nil
 }
 
-// SetAttributes sets the Attributes.  This will not be displayed immediately.
-// SetAttributes shall be called from queue context.
-func (l *List) SetAttributes(a Attributes) {
-	l.attr = a
+// SetStyle sets the Style.  This will not be displayed immediately.
+// SetStyle shall be called from queue context.
+func (l *List) SetStyle(s Style) {
+	l.style = s
 }
 
+// Resize implements the interface.  The list is placed according to the
+// window layout or its anchor point and size.  This is called from queue
+// context so be careful to not use blocking calls.
 func (l *List) Resize() {
-	l.trueX = l.x
-	l.trueY = l.y
-	l.trueW = l.width
-	l.trueH = l.height
-
-	// width < 1 means x - width
-	if l.width < 1 {
-		l.trueW = l.w.x + l.width
-	}
-
-	// height < 1 means y - height
-	if l.height < 1 {
-		l.trueH = l.w.y + l.height - 1
-	}
+	r := l.Bounds(l.width, l.height)
+	l.trueX = r.X
+	l.trueY = r.Y
+	l.trueW = r.Width
+	l.trueH = r.Height
 
 	// check if we need to update l.at
 	if l.at != 0 && l.at+l.trueH >= len(l.content) {
@@ -133,6 +130,12 @@ func (l *List) Resize() {
 	}
 }
 
+// MinSize implements the MinSizer interface.  A list requires at least one
+// line.
+func (l *List) MinSize() (int, int) {
+	return l.MinBounds(l.width, l.height, 1, 1)
+}
+
 // AddList is a convenience function to add a new list to a window.  It wraps
 // the AddWidget call.  AddList must be called from queue.
 func (w *Window) AddList(x, y, width, height int) *List {
@@ -142,7 +145,8 @@ func (w *Window) AddList(x, y, width, height int) *List {
 	list.width = width
 	list.height = height
 	list.Resize()
-	list.SetAttributes(defaultAttributes())
+	list.SetStyle(defaultStyle())
+	list.activity = ActivityNormal
 
 	list.content = make([]string, 0, 1000)
 	return list
@@ -152,6 +156,10 @@ func (w *Window) AddList(x, y, width, height int) *List {
 func (l *List) Append(format string, args ...interface{}) {
 	s := fmt.Sprintf(format, args...)
 	l.content = append(l.content, s)
+	l.w.SetActivity(l.activity)
+	if l.w == inputWindow() && l.visibility != VisibilityHide {
+		announce("%v", s)
+	}
 
 	// adjust at if we are not in a paging operation
 	if l.paging {
@@ -163,6 +171,18 @@ func (l *List) Append(format string, args ...interface{}) {
 	}
 }
 
+// Describe implements the Describer interface.
+func (l *List) Describe() string {
+	return fmt.Sprintf("list: %v, %v lines", l.name, len(l.content))
+}
+
+// SetActivity sets the activity that Append raises on the window while it is
+// not focused.  The default is ActivityNormal, ActivityNone disables it.
+// SetActivity shall be called from queue context.
+func (l *List) SetActivity(level Activity) {
+	l.activity = level
+}
+
 // Location are hints for the Display function.
 type Location int
 
@@ -177,7 +197,8 @@ const (
 // Display renders the widget.  This is called from queue context so be careful
 // to not use blocking calls.
 func (l *List) Display(where Location) {
-	if len(l.content) == 0 || l.visibility == VisibilityHide {
+	if len(l.content) == 0 || l.visibility == VisibilityHide ||
+		l.trueW < 1 || l.trueH < 1 {
 		return
 	}
 
@@ -218,7 +239,11 @@ func (l *List) Display(where Location) {
 		return
 	}
 
-	c = c[l.at : l.at+l.trueH]
+	end := l.at + l.trueH
+	if end > len(c) {
+		end = len(c)
+	}
+	c = c[l.at:end]
 
 	// create a buffer with all lines neatly clipped
 	buffer := make([][]rune, 0, l.trueH*2)
@@ -265,8 +290,7 @@ func (l *List) Display(where Location) {
 		}
 		if leftover != "" {
 			// done clipping, next line
-			filler := strings.Repeat(" ", l.trueW-printWidth)
-			buffer = append(buffer, []rune(cc+leftover+filler))
+			buffer = append(buffer, []rune(cc+leftover))
 		}
 	}
 
@@ -275,9 +299,11 @@ func (l *List) Display(where Location) {
 	if len(buffer) > l.trueH {
 		buffer = buffer[len(buffer)-l.trueH:]
 	}
-	x := 0
 	for i, v := range buffer {
-		l.w.printf(x, l.trueY+i, l.attr, "%v", string(v))
+		// print the filler separately so that it is not reordered
+		n := l.w.print(l.trueX, l.trueY+i, l.trueW, l.style, string(v))
+		l.w.print(l.trueX+n, l.trueY+i, l.trueW-n, l.style,
+			strings.Repeat(" ", l.trueW-n))
 	}
 }
 
@@ -0,0 +1,116 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// wipe overwrites runes with zeroes.
+func wipe(r []rune) {
+	for i := range r {
+		r[i] = 0
+	}
+}
+
+// SetMasked switches masked mode on or off.  A masked edit, i.e. for a
+// passphrase, displays mask for every rune or nothing at all if mask is 0.
+// The text is never converted to a string: the target of SetText is not
+// updated, OnChange and OnSubmit are called with an empty string and GetText
+// returns an empty string; use Bytes to read the text.  The text is wiped when
+// it is cleared and after OnSubmit returns.  Without OnSubmit the submit key is
+// forwarded to the application with the text intact; the application must then
+// read it with Bytes and call Wipe.  Masked edits do not use the history,
+// completion, undo or the kill ring.  This will not be displayed immediately.
+// SetMasked shall be called from queue context.
+func (e *Edit) SetMasked(masked bool, mask rune) {
+	e.masked = masked
+	e.mask = mask
+	if masked {
+		e.endCompletion()
+		e.searching = false
+		for _, s := range append(e.undo, e.redo...) {
+			wipe(s.text)
+		}
+		e.undo = nil
+		e.redo = nil
+	}
+}
+
+// Masked returns true if the edit is in masked mode.
+// Masked shall be called from queue context.
+func (e *Edit) Masked() bool {
+	return e.masked
+}
+
+// Bytes returns the text encoded as UTF-8.  The returned slice is a copy that
+// the caller should wipe once it is done with it.
+// Bytes shall be called from queue context.
+func (e *Edit) Bytes() []byte {
+	n := 0
+	for _, r := range e.display {
+		n += utf8.RuneLen(r)
+	}
+	b := make([]byte, n)
+	i := 0
+	for _, r := range e.display {
+		i += utf8.EncodeRune(b[i:], r)
+	}
+	return b
+}
+
+// Wipe overwrites the text with zeroes and clears the edit.
+// Wipe shall be called from queue context.
+func (e *Edit) Wipe() {
+	wipe(e.display[:cap(e.display)])
+	e.display = e.display[:0]
+	e.at = 0
+	if e.cx >= 0 {
+		e.cx = e.trueX
+	}
+	e.Render()
+	if e.cx >= 0 && e.cy >= 0 && e.w.focusedWidget() == Widgeter(e) {
+		e.setCursor()
+	}
+}
+
+// reserve makes room for n more runes.  In masked mode the text is moved
+// explicitly so that no copy of it is left behind by append.
+// reserve shall be called from queue context.
+func (e *Edit) reserve(n int) {
+	if !e.masked || len(e.display)+n <= cap(e.display) {
+		return
+	}
+	text := make([]rune, len(e.display), 2*cap(e.display)+n)
+	copy(text, e.display)
+	wipe(e.display[:cap(e.display)])
+	e.display = text
+}
+
+// renderMasked displays the mask instead of the text.
+// renderMasked shall be called from queue context.
+func (e *Edit) renderMasked() {
+	n := 0
+	if e.mask != 0 {
+		n = e.w.print(e.trueX, e.trueY, e.trueW, e.style,
+			strings.Repeat(string(e.mask), len(e.visible())))
+	}
+	e.w.print(e.trueX+n, e.trueY, e.trueW-n, e.style,
+		strings.Repeat(" ", e.trueW-n))
+}
+
+// submitMasked calls the OnSubmit function, which reads the text with Bytes,
+// and wipes the text afterwards.  Without OnSubmit the text is left for the
+// application.
+// submitMasked shall be called from queue context.
+func (e *Edit) submitMasked() bool {
+	if e.onSubmit == nil {
+		return false
+	}
+	used := e.onSubmit("")
+	e.Wipe()
+	return used
+}
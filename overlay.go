@@ -0,0 +1,205 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+var (
+	// overlays are drawn on top of the focused window in this order, the
+	// last overlay is on top.
+	overlays []*Window
+)
+
+// Overlay describes the placement of an overlay window such as a popup,
+// dialog, dropdown or tooltip.  Overlays are drawn on top of the focused
+// window and restore what was underneath when closed.
+type Overlay struct {
+	// Widget is the widget the overlay is placed relative to.  If nil
+	// the overlay is placed relative to the screen.
+	Widget Widgeter
+
+	// X and Y are the offset of the top left corner relative to the
+	// widget or the screen.  When relative to the screen a negative X or
+	// Y is counted from the right or bottom edge, -1 being flush.
+	X int
+	Y int
+
+	// Width and Height are the size of the overlay.  The overlay is
+	// clipped to the screen.
+	Width  int
+	Height int
+
+	// Center places the overlay in the middle of the screen.  X and Y are
+	// ignored.
+	Center bool
+
+	// Modal overlays receive all keyboard input until closed.
+	Modal bool
+}
+
+// placer is implemented by widgets that know where they are drawn.
+type placer interface {
+	window() *Window      // window that contains widget
+	position() (int, int) // window coordinates of the widget
+}
+
+// NewOverlay creates a new overlay window and places it on top of all other
+// overlays.  The Windower is used exactly like it is for NewWindow.
+func NewOverlay(manager Windower, o Overlay) *Window {
+	wc := make(chan *Window)
+	Queue(func() {
+		w := newOverlay(manager, o)
+		flush()
+		wc <- w
+	})
+	return <-wc
+}
+
+// newOverlay creates a new overlay window, places it on top of all other
+// overlays and renders it.  This will not show until flushed.
+// newOverlay shall be called from queue context.
+func newOverlay(manager Windower, o Overlay) *Window {
+	w := newWindow(manager, 0, 0)
+	w.overlay = &o
+	w.place()
+	overlays = append(overlays, w)
+	manager.Init(w)
+	w.render()
+	return w
+}
+
+// CloseOverlay removes the overlay window from the screen.  Whatever was
+// underneath is displayed again.
+func CloseOverlay(w *Window) {
+	Queue(func() {
+		closeOverlay(w)
+		flush()
+	})
+}
+
+// closeOverlay removes the overlay from the overlay stack.
+// closeOverlay shall be called from queue context.
+func closeOverlay(w *Window) {
+	for i, o := range overlays {
+		if o != w {
+			continue
+		}
+		copy(overlays[i:], overlays[i+1:])
+		overlays[len(overlays)-1] = nil
+		overlays = overlays[:len(overlays)-1]
+		delete(windower2window, w.mgr)
+		return
+	}
+}
+
+// place calculates the screen position and size of an overlay window and
+// resizes it accordingly.
+// place shall be called from queue context.
+func (w *Window) place() {
+	o := w.overlay
+	width := o.Width
+	height := o.Height
+	if width > maxX {
+		width = maxX
+	}
+	if height > maxY {
+		height = maxY
+	}
+
+	var x, y int
+	switch {
+	case o.Center:
+		x = (maxX - width) / 2
+		y = (maxY - height) / 2
+	case o.Widget != nil:
+		p, ok := o.Widget.(placer)
+		if !ok {
+			break
+		}
+		wx, wy := p.position()
+		wx += p.window().originX
+		wy += p.window().originY
+		x = wx + o.X
+		y = wy + o.Y
+
+		// flip above the widget if there is no room below
+		if y+height > maxY && wy-height >= 0 {
+			y = wy - height
+		}
+	default:
+		x = o.X
+		y = o.Y
+		if x < 0 {
+			x = maxX - width + x + 1
+		}
+		if y < 0 {
+			y = maxY - height + y + 1
+		}
+	}
+
+	// keep it on screen
+	if x+width > maxX {
+		x = maxX - width
+	}
+	if y+height > maxY {
+		y = maxY - height
+	}
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+	w.originX = x
+	w.originY = y
+	w.resize(width, height)
+}
+
+// inputWindow returns the window that receives keyboard input.  This is the
+// top most modal overlay or the focused window.
+// inputWindow shall be called from queue context.
+func inputWindow() *Window {
+	for i := len(overlays) - 1; i >= 0; i-- {
+		if overlays[i].overlay.Modal {
+			return overlays[i]
+		}
+	}
+	return focus
+}
+
+// screenCell returns the cell that is displayed at the screen coordinate.
+// screenCell shall be called from queue context.
+func screenCell(x, y int) Cell {
+	c := Cell{Ch: ' '}
+	c.Bg = bg
+
+	w := focus
+	if tiling != nil {
+		var r rune
+		w, r = tiling.cellAt(x, y)
+		if r != 0 {
+			c.Ch = r
+		}
+	}
+	for i := len(overlays) - 1; i >= 0; i-- {
+		o := overlays[i]
+		if x >= o.originX && x < o.originX+o.x &&
+			y >= o.originY && y < o.originY+o.y {
+			w = o
+			break
+		}
+	}
+
+	if w == nil {
+		return c
+	}
+	x -= w.originX
+	y -= w.originY
+	if x >= 0 && y >= 0 && x < w.x && y < w.y {
+		c = *w.getCell(x, y)
+		if c.Ch == 0 {
+			c.Ch = ' '
+		}
+	}
+	return c
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"testing"
+)
+
+var (
+	_ backend = (*screen)(nil) // ensure interface is satisfied
+)
+
+// screen is a backend that keeps the cells that flush sends so that tests can
+// look at what is displayed.
+type screen struct {
+	width  int
+	height int
+	cells  []Cell
+	keys   chan Key
+	done   chan struct{}
+}
+
+func newScreen(width, height int) *screen {
+	return &screen{
+		width:  width,
+		height: height,
+		cells:  make([]Cell, width*height),
+		keys:   make(chan Key),
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *screen) init() error {
+	return nil
+}
+
+func (s *screen) close() {
+	close(s.done)
+}
+
+func (s *screen) size() (int, int) {
+	return s.width, s.height
+}
+
+func (s *screen) clear(st Style) {
+	for i := range s.cells {
+		s.cells[i] = Cell{Ch: ' ', Style: Style{Bg: st.Bg}}
+	}
+}
+
+func (s *screen) setCell(x, y int, c Cell) {
+	s.cells[x+y*s.width] = c
+}
+
+func (s *screen) setCursor(x, y int) {}
+
+func (s *screen) setCursorShape(CursorShape) {}
+
+func (s *screen) flush() (int, error) {
+	return 0, nil
+}
+
+func (s *screen) pollEvent() event {
+	select {
+	case k := <-s.keys:
+		return event{typ: eventKey, key: k}
+	case <-s.done:
+		return event{typ: eventError, err: errBackendClosed}
+	}
+}
+
+// line returns line y of the screen.
+func (s *screen) line(y int) string {
+	c := make(chan string)
+	Queue(func() {
+		line := ""
+		for x := 0; x < s.width; x++ {
+			line += string(s.cells[x+y*s.width].Ch)
+		}
+		c <- line
+	})
+	return <-c
+}
+
+// initScreen drives a screen of the provided size instead of a terminal.
+func initScreen(t *testing.T, width, height int) *screen {
+	s := newScreen(width, height)
+	if err := initBackend(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// fillWindow fills the entire window with a rune.
+type fillWindow struct {
+	ch rune
+}
+
+func (fw *fillWindow) Init(w *Window) {}
+
+func (fw *fillWindow) Render(w *Window) {
+	c := w.Canvas()
+	width, height := c.Size()
+	c.Fill(Rect{Width: width, Height: height}, fw.ch, c.DefaultStyle())
+}
+
+func (fw *fillWindow) KeyHandler(w *Window, k Key) {}
+
+func TestOverlayPlace(t *testing.T) {
+	defer func(x, y int) { maxX, maxY = x, y }(maxX, maxY)
+	maxX, maxY = 20, 10
+
+	base, done := newTestWindow(20, 10)
+	defer done()
+	base.originX = 3 // i.e. the right pane
+	e := base.AddEditText(2, 5, 6, "")
+	low := base.AddEditText(2, 8, 6, "")
+	right := base.AddEditText(15, 2, 4, "")
+
+	tests := []struct {
+		name string
+		o    Overlay
+		want Rect
+	}{
+		{"screen", Overlay{X: 2, Y: 1, Width: 5, Height: 2},
+			Rect{X: 2, Y: 1, Width: 5, Height: 2}},
+		{"from edge", Overlay{X: -1, Y: -1, Width: 5, Height: 2},
+			Rect{X: 15, Y: 8, Width: 5, Height: 2}},
+		{"right edge", Overlay{X: 18, Y: 9, Width: 5, Height: 2},
+			Rect{X: 15, Y: 8, Width: 5, Height: 2}},
+		{"too large", Overlay{X: 4, Width: 30, Height: 20},
+			Rect{Width: 20, Height: 10}},
+		{"center", Overlay{X: 5, Width: 4, Height: 2, Center: true},
+			Rect{X: 8, Y: 4, Width: 4, Height: 2}},
+		{"widget", Overlay{Widget: e, Y: 1, Width: 6, Height: 3},
+			Rect{X: 5, Y: 6, Width: 6, Height: 3}},
+		{"flip", Overlay{Widget: low, Y: 1, Width: 6, Height: 3},
+			Rect{X: 5, Y: 5, Width: 6, Height: 3}},
+		{"widget edge", Overlay{Widget: right, Y: 1, Width: 6, Height: 3},
+			Rect{X: 14, Y: 3, Width: 6, Height: 3}},
+	}
+	for _, test := range tests {
+		w, done := newTestWindow(0, 0)
+		w.overlay = &test.o
+		w.place()
+		done()
+		got := Rect{X: w.originX, Y: w.originY, Width: w.x, Height: w.y}
+		if got != test.want {
+			t.Errorf("%v: got %+v want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestOverlayCompositing(t *testing.T) {
+	s := initScreen(t, 20, 3)
+	defer Deinit()
+
+	Focus(NewWindow(&funcWindow{init: helloWorld}))
+	waitQueue(t, "base", func() bool {
+		return focus != nil && focus.x == 20
+	})
+	if line := s.line(1); line != " hello world        " {
+		t.Fatalf("base: got %q", line)
+	}
+
+	// the last overlay is on top
+	a := NewOverlay(&fillWindow{ch: 'a'}, Overlay{Y: 1, Width: 4, Height: 1})
+	b := NewOverlay(&fillWindow{ch: 'b'}, Overlay{X: 2, Y: 1, Width: 4,
+		Height: 1})
+	if line := s.line(1); line != "aabbbb world        " {
+		t.Fatalf("overlays: got %q", line)
+	}
+
+	// closing restores what was underneath
+	CloseOverlay(b)
+	if line := s.line(1); line != "aaaalo world        " {
+		t.Fatalf("close top: got %q", line)
+	}
+	CloseOverlay(a)
+	if line := s.line(1); line != " hello world        " {
+		t.Fatalf("close: got %q", line)
+	}
+	for _, y := range []int{0, 2} {
+		if line := s.line(y); line != "                    " {
+			t.Fatalf("line %v: got %q", y, line)
+		}
+	}
+}
+
+func TestOverlayModal(t *testing.T) {
+	s := initScreen(t, 20, 3)
+	defer Deinit()
+
+	var base, modal, other *Edit
+	Focus(NewWindow(&funcWindow{init: addEdit(&base, 10, "abc")}))
+	typed := func(ch rune, what string, f func() bool) {
+		s.keys <- Key{Ch: ch}
+		waitQueue(t, what, f)
+	}
+
+	// a modal overlay takes the keys from the focused window
+	o := NewOverlay(&funcWindow{init: addEdit(&modal, 0, "")},
+		Overlay{Y: 2, Width: 10, Height: 1, Modal: true})
+	typed('x', "modal", func() bool {
+		return modal.GetText() == "x" && base.GetText() == "abc"
+	})
+
+	// a non modal overlay does not
+	CloseOverlay(o)
+	NewOverlay(&funcWindow{init: addEdit(&other, 0, "")},
+		Overlay{Y: 2, Width: 10, Height: 1})
+	typed('y', "focused", func() bool {
+		return other.GetText() == "" && base.GetText() == "abcy"
+	})
+}
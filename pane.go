@@ -0,0 +1,382 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+// Pane actions.  They are handled by ttk while tiling and are not bound by
+// default.
+const (
+	ActionPaneNext     = "pane.next"     // focus on next pane
+	ActionPanePrevious = "pane.previous" // focus on previous pane
+	ActionPaneGrow     = "pane.grow"     // grow focused pane by a cell
+	ActionPaneShrink   = "pane.shrink"   // shrink focused pane by a cell
+)
+
+var (
+	// tiling is the root of the pane layout, nil means the focused window
+	// is displayed full screen.
+	tiling *Pane
+)
+
+// init registers the pane window actions.
+func init() {
+	windowActions[ActionPaneNext] = func(*Window) { focusPane(1) }
+	windowActions[ActionPanePrevious] = func(*Window) { focusPane(-1) }
+	windowActions[ActionPaneGrow] = func(*Window) { resizePane(1) }
+	windowActions[ActionPaneShrink] = func(*Window) { resizePane(-1) }
+}
+
+// Split is the direction in which a Pane divides its area among its
+// children.
+type Split int
+
+// Split directions.
+const (
+	SplitColumns Split = iota // children side by side
+	SplitRows                 // children stacked
+)
+
+// PaneSize is the size of a Pane within its parent split.  Fixed panes are
+// sized first, the remaining space is divided among the other panes in
+// proportion to their Ratio.
+type PaneSize struct {
+	Fixed int // size in cells, 0 if not fixed
+	Ratio int // share of the remaining space, 0 is treated as 1
+}
+
+// Pane is a node in the tiling layout.  A leaf pane displays a Window, other
+// panes split their area among their children with divider lines in between.
+type Pane struct {
+	Size PaneSize // size within the parent
+
+	window   *Window // leaf only
+	split    Split   // split only
+	children []*Pane // split only
+	parent   *Pane
+
+	// area assigned on the last layout
+	x      int
+	y      int
+	width  int
+	height int
+}
+
+// NewPane returns a leaf pane that displays the provided window.
+func NewPane(w *Window, size PaneSize) *Pane {
+	return &Pane{
+		Size:   size,
+		window: w,
+	}
+}
+
+// NewSplit returns a pane that divides its area among the children in the
+// provided direction.
+func NewSplit(s Split, size PaneSize, children ...*Pane) *Pane {
+	p := &Pane{
+		Size:     size,
+		split:    s,
+		children: children,
+	}
+	for _, c := range children {
+		c.parent = p
+	}
+	return p
+}
+
+// leaves returns all leaf panes in display order.
+func (p *Pane) leaves() []*Pane {
+	if p.window != nil {
+		return []*Pane{p}
+	}
+	var l []*Pane
+	for _, c := range p.children {
+		l = append(l, c.leaves()...)
+	}
+	return l
+}
+
+// find returns the leaf pane that displays w.
+func (p *Pane) find(w *Window) *Pane {
+	for _, l := range p.leaves() {
+		if l.window == w {
+			return l
+		}
+	}
+	return nil
+}
+
+// remove takes the pane out of the layout.  A split that is left with a single
+// child is replaced by that child.  It returns the new root of the layout,
+// which is nil if p was the root.
+func (p *Pane) remove(root *Pane) *Pane {
+	parent := p.parent
+	if parent == nil {
+		return nil
+	}
+	for i, c := range parent.children {
+		if c == p {
+			parent.children = append(parent.children[:i],
+				parent.children[i+1:]...)
+			break
+		}
+	}
+	p.parent = nil
+	if len(parent.children) != 1 {
+		return root
+	}
+
+	only := parent.children[0]
+	only.Size = parent.Size
+	only.parent = parent.parent
+	if only.parent == nil {
+		return only
+	}
+	for i, c := range only.parent.children {
+		if c == parent {
+			only.parent.children[i] = only
+		}
+	}
+	return root
+}
+
+// extent returns the size of the pane in the direction of the parent split.
+func (p *Pane) extent() int {
+	if p.parent != nil && p.parent.split == SplitRows {
+		return p.height
+	}
+	return p.width
+}
+
+// sizes divides avail cells among the children.
+func (p *Pane) sizes(avail int) []int {
+	sizes := make([]int, len(p.children))
+	ratios := 0
+	for i, c := range p.children {
+		if c.Size.Fixed > 0 {
+			sizes[i] = c.Size.Fixed
+			if sizes[i] > avail {
+				sizes[i] = avail
+			}
+			avail -= sizes[i]
+			continue
+		}
+		ratios += c.ratio()
+	}
+
+	// divide the remainder, the last ratio pane gets the rounding error
+	last := -1
+	left := avail
+	for i, c := range p.children {
+		if c.Size.Fixed > 0 {
+			continue
+		}
+		sizes[i] = avail * c.ratio() / ratios
+		left -= sizes[i]
+		last = i
+	}
+	if last >= 0 {
+		sizes[last] += left
+	}
+
+	return sizes
+}
+
+// ratio returns the share of a pane, 0 is treated as 1.
+func (p *Pane) ratio() int {
+	if p.Size.Ratio < 1 {
+		return 1
+	}
+	return p.Size.Ratio
+}
+
+// layout assigns the area to the pane and its children and resizes and
+// renders the windows.
+// layout shall be called from queue context.
+func (p *Pane) layout(x, y, width, height int) {
+	p.x = x
+	p.y = y
+	p.width = width
+	p.height = height
+
+	if p.window != nil {
+		p.window.originX = x
+		p.window.originY = y
+		p.window.resize(width, height)
+		p.window.render()
+		return
+	}
+	if len(p.children) == 0 {
+		return
+	}
+
+	// leave room for dividers
+	total := width
+	if p.split == SplitRows {
+		total = height
+	}
+	avail := total - (len(p.children) - 1)
+	if avail < 0 {
+		avail = 0
+	}
+
+	for i, size := range p.sizes(avail) {
+		c := p.children[i]
+		if p.split == SplitRows {
+			c.layout(x, y, width, size)
+			y += size + 1
+		} else {
+			c.layout(x, y, size, height)
+			x += size + 1
+		}
+	}
+}
+
+// cellAt returns the window displayed at the screen coordinate.  If the
+// coordinate is on a divider it returns the divider rune instead.
+func (p *Pane) cellAt(x, y int) (*Window, rune) {
+	if x < p.x || y < p.y || x >= p.x+p.width || y >= p.y+p.height {
+		return nil, 0
+	}
+	if p.window != nil {
+		return p.window, 0
+	}
+	for _, c := range p.children {
+		w, r := c.cellAt(x, y)
+		if w != nil || r != 0 {
+			return w, r
+		}
+	}
+	if p.split == SplitRows {
+		return nil, '─'
+	}
+	return nil, '│'
+}
+
+// Tile displays the windows of the pane layout side by side or stacked.  Each
+// window is resized to the area of its pane.  If the focused window is not
+// part of the layout the first pane is focused.  Tile(nil) returns to
+// displaying the focused window full screen.
+func Tile(root *Pane) {
+	Queue(func() {
+		tiling = root
+		if root != nil && root.find(focus) == nil {
+			l := root.leaves()
+			if len(l) > 0 {
+				prevFocus = focus
+				focus = l[0].window
+				focus.clearActivity()
+			}
+		}
+		resizeAndRender(focus)
+	})
+}
+
+// focusPane moves focus to the pane that is offset panes away from the
+// focused one.
+// focusPane shall be called from queue context.
+func focusPane(offset int) {
+	if tiling == nil {
+		return
+	}
+	l := tiling.leaves()
+	for i, p := range l {
+		if p.window != focus {
+			continue
+		}
+		i = (i + offset + len(l)) % len(l)
+		if l[i].window == focus {
+			return
+		}
+		focus.blur()
+		prevFocus = focus
+		focus = l[i].window
+		focus.clearActivity()
+
+		if focus.title != "" {
+			announce("window: %v", focus.title)
+		}
+		prev := focus.focusedWidget()
+		focus.focusWidget()
+		if widget := focus.focusedWidget(); widget == prev {
+			// focusIndex did not announce it
+			announceWidget(widget)
+		}
+		flush()
+		return
+	}
+}
+
+// FocusNextPane focuses on the window in the next pane.
+func FocusNextPane() {
+	Queue(func() {
+		focusPane(1)
+	})
+}
+
+// FocusPreviousPane focuses on the window in the previous pane.
+func FocusPreviousPane() {
+	Queue(func() {
+		focusPane(-1)
+	})
+}
+
+// ResizePane grows the focused pane by delta cells, or shrinks it if delta is
+// negative, at the expense of its neighbor in the parent split.
+func ResizePane(delta int) {
+	Queue(func() {
+		resizePane(delta)
+	})
+}
+
+// resizePane grows the focused pane by delta cells, or shrinks it if delta is
+// negative, at the expense of its neighbor in the parent split.
+// resizePane shall be called from queue context.
+func resizePane(delta int) {
+	if tiling == nil {
+		return
+	}
+	p := tiling.find(focus)
+	if p == nil || p.parent == nil || len(p.parent.children) < 2 {
+		return
+	}
+
+	// use the current sizes as ratios so that the change is
+	// exactly delta cells
+	siblings := p.parent.children
+	var i int
+	for k, c := range siblings {
+		if c == p {
+			i = k
+		}
+		if c.Size.Fixed == 0 {
+			c.Size.Ratio = c.extent()
+		}
+	}
+	j := i + 1
+	if j == len(siblings) {
+		j = i - 1
+	}
+
+	grow := func(c *Pane, d int) int {
+		v := &c.Size.Ratio
+		if c.Size.Fixed > 0 {
+			v = &c.Size.Fixed
+		}
+		if *v+d < 1 {
+			d = 1 - *v
+		}
+		*v += d
+		return d
+	}
+	// never take more than the shrinking pane has
+	if delta > 0 {
+		delta = -grow(siblings[j], -delta)
+		grow(p, delta)
+	} else {
+		delta = grow(p, delta)
+		grow(siblings[j], -delta)
+	}
+
+	resizeAndRender(focus)
+}
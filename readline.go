@@ -0,0 +1,209 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Readline style edit actions.  They are bound in the WidgetEdit keymap.
+const (
+	ActionEditWordLeft         = "edit.word-left"          // cursor to begin of word
+	ActionEditWordRight        = "edit.word-right"         // cursor to end of word
+	ActionEditKillWordBackward = "edit.kill-word-backward" // kill to begin of word
+	ActionEditKillWord         = "edit.kill-word"          // kill to end of word
+	ActionEditKillToEnd        = "edit.kill-to-end"        // kill to end of text
+	ActionEditYank             = "edit.yank"               // insert last kill
+	ActionEditYankPop          = "edit.yank-pop"           // replace yank with older kill
+	ActionEditTranspose        = "edit.transpose"          // swap characters
+	ActionEditRedraw           = "edit.redraw"             // repaint terminal
+)
+
+// killRingSize is the number of kills that are remembered.
+const killRingSize = 16
+
+var (
+	// kill ring that is shared by all edits, newest kill last.  Only
+	// accessed from queue context.
+	killRing []string
+	killIdx  int // kill that was inserted by the last yank
+)
+
+// isKill returns true if the action adds to the kill ring.
+func isKill(action string) bool {
+	switch action {
+	case ActionEditKillLine, ActionEditKillWordBackward,
+		ActionEditKillWord, ActionEditKillToEnd:
+		return true
+	}
+	return false
+}
+
+// isWordRune returns true if r is part of a word for word motion.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isNotSpace returns true if r is part of a white space separated word.
+func isNotSpace(r rune) bool {
+	return !unicode.IsSpace(r)
+}
+
+// index returns the index of the rune in front of the cursor.
+func (e *Edit) index() int {
+	return e.cx - e.trueX + e.at
+}
+
+// wordLeft returns the index of the begin of the word in front of i.
+func (e *Edit) wordLeft(i int, word func(rune) bool) int {
+	for i > 0 && !word(e.display[i-1]) {
+		i--
+	}
+	for i > 0 && word(e.display[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordRight returns the index behind the end of the word at or after i.
+func (e *Edit) wordRight(i int, word func(rune) bool) int {
+	for i < len(e.display) && !word(e.display[i]) {
+		i++
+	}
+	for i < len(e.display) && word(e.display[i]) {
+		i++
+	}
+	return i
+}
+
+// move places the cursor in front of the rune at index i and displays it.
+// move shall be called from queue context.
+func (e *Edit) move(i int) {
+	e.moveCursor(i)
+	e.Render()
+	e.setCursor()
+}
+
+// kill removes the runes from start to end and adds them to the kill ring.
+// Consecutive kills are collected in a single kill, text that is killed
+// backwards is prepended.
+// kill shall be called from queue context.
+func (e *Edit) kill(start, end int, last string) {
+	if start >= end {
+		return
+	}
+	if e.masked {
+		// masked text is not copied to the kill ring
+		e.replace(start, end, "")
+		return
+	}
+	text := string(e.display[start:end])
+	switch {
+	case isKill(last) && len(killRing) > 0 && start < e.index():
+		killRing[len(killRing)-1] = text + killRing[len(killRing)-1]
+	case isKill(last) && len(killRing) > 0:
+		killRing[len(killRing)-1] += text
+	default:
+		killRing = append(killRing, text)
+		if len(killRing) > killRingSize {
+			killRing = killRing[len(killRing)-killRingSize:]
+		}
+	}
+	e.replace(start, end, "")
+}
+
+// yank inserts the last kill in front of the cursor.
+// yank shall be called from queue context.
+func (e *Edit) yank() {
+	if len(killRing) == 0 {
+		return
+	}
+	killIdx = len(killRing) - 1
+	i := e.index()
+	e.replace(i, i, killRing[killIdx])
+}
+
+// yankPop replaces the text that was just yanked with the previous kill.  It
+// does nothing unless the last action was a yank.
+// yankPop shall be called from queue context.
+func (e *Edit) yankPop(last string) {
+	if (last != ActionEditYank && last != ActionEditYankPop) ||
+		len(killRing) == 0 {
+		e.last = ""
+		return
+	}
+	end := e.index()
+	start := end - utf8.RuneCountInString(killRing[killIdx])
+	killIdx = (killIdx - 1 + len(killRing)) % len(killRing)
+	e.replace(start, end, killRing[killIdx])
+}
+
+// transpose swaps the rune in front of the cursor with the rune under it and
+// moves the cursor forward.  At the end of the text the two runes in front of
+// the cursor are swapped.
+// transpose shall be called from queue context.
+func (e *Edit) transpose() {
+	i := e.index()
+	if i == len(e.display) {
+		i--
+	}
+	if i < 1 {
+		return
+	}
+	e.saveUndo(false)
+	e.display[i-1], e.display[i] = e.display[i], e.display[i-1]
+	e.move(i + 1)
+	e.changed()
+}
+
+// redraw clears the terminal and repaints the focused window and overlays,
+// i.e. after another program wrote to the terminal.
+// redraw shall be called from queue context.
+func redraw() {
+	if term == nil {
+		return
+	}
+	term.clear(defaultStyle())
+	resetFront()
+	resizeAndRender(focus)
+}
+
+// Redraw clears the terminal and repaints the focused window.
+func Redraw() {
+	Queue(func() {
+		redraw()
+	})
+}
+
+// readlineKey handles the readline style actions.  It returns true if the
+// action is one of them.  Last is the action of the previous key.
+// readlineKey shall be called from queue context.
+func (e *Edit) readlineKey(ev Key, last string) bool {
+	i := e.index()
+	switch ev.Action {
+	case ActionEditWordLeft:
+		e.move(e.wordLeft(i, isWordRune))
+	case ActionEditWordRight:
+		e.move(e.wordRight(i, isWordRune))
+	case ActionEditKillWordBackward:
+		e.kill(e.wordLeft(i, isNotSpace), i, last)
+	case ActionEditKillWord:
+		e.kill(i, e.wordRight(i, isWordRune), last)
+	case ActionEditKillToEnd:
+		e.kill(i, len(e.display), last)
+	case ActionEditYank:
+		e.yank()
+	case ActionEditYankPop:
+		e.yankPop(last)
+	case ActionEditTranspose:
+		e.transpose()
+	case ActionEditRedraw:
+		redraw()
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import "sort"
+
+// Activity is the level of unseen activity in a window that is not focused.
+// Higher levels take precedence over lower ones.
+type Activity int
+
+// Activity levels.
+const (
+	ActivityNone   Activity = iota // nothing new
+	ActivityLow                    // new content of little interest
+	ActivityNormal                 // new content, i.e. a line in a List
+	ActivityHigh                   // content that needs attention
+)
+
+var (
+	// activityHandler is called whenever the activity of a window
+	// changes.
+	activityHandler func(*Window, Activity)
+)
+
+// Windows returns all windows in creation order.  Overlays are not included.
+func Windows() []*Window {
+	wc := make(chan []*Window)
+	Queue(func() {
+		wc <- sortedWindows()
+	})
+	return <-wc
+}
+
+// sortedWindows returns all windows in creation order.
+// sortedWindows shall be called from queue context.
+func sortedWindows() []*Window {
+	ws := make([]*Window, 0, len(windows))
+	for _, w := range windows {
+		ws = append(ws, w)
+	}
+	sort.Slice(ws, func(i, j int) bool {
+		return ws[i].id < ws[j].id
+	})
+	return ws
+}
+
+// LookupWindow returns the window that is managed by the provided Windower or
+// nil if there is none.
+func LookupWindow(manager Windower) *Window {
+	wc := make(chan *Window)
+	Queue(func() {
+		wc <- windower2window[manager]
+	})
+	return <-wc
+}
+
+// ID returns the window id.  Ids are handed out in creation order.
+func (w *Window) ID() int {
+	return w.id
+}
+
+// Title returns the window title.
+// Title shall be called from queue context.
+func (w *Window) Title() string {
+	return w.title
+}
+
+// SetTitle sets the window title.  ttk does not display the title, it is
+// meant for application window bars and lists.
+// SetTitle shall be called from queue context.
+func (w *Window) SetTitle(title string) {
+	w.title = title
+}
+
+// Activity returns the level of unseen activity in the window.  The activity
+// is reset when the window is focused.
+// Activity shall be called from queue context.
+func (w *Window) Activity() Activity {
+	return w.activity
+}
+
+// SetActivity raises the activity of the window to level.  It does nothing if
+// the window is displayed, i.e. focused or tiled, or if its activity already
+// is at or above level.
+// SetActivity shall be called from queue context.
+func (w *Window) SetActivity(level Activity) {
+	if w == focus || level <= w.activity {
+		return
+	}
+	if tiling != nil && tiling.find(w) != nil {
+		return
+	}
+	w.activity = level
+	if activityHandler != nil {
+		activityHandler(w, level)
+	}
+}
+
+// clearActivity resets the activity of the window once it is seen.
+// clearActivity shall be called from queue context.
+func (w *Window) clearActivity() {
+	if w == nil || w.activity == ActivityNone {
+		return
+	}
+	w.activity = ActivityNone
+	if activityHandler != nil {
+		activityHandler(w, ActivityNone)
+	}
+}
+
+// SetActivityHandler sets the function that is called whenever the activity of
+// a window changes, for example to update a window bar.  The handler is
+// called from queue context and shall not use blocking calls.
+func SetActivityHandler(f func(*Window, Activity)) {
+	Queue(func() {
+		activityHandler = f
+	})
+}
+
+// FocusNextActive focuses on the window with the highest activity.  Among
+// windows with the same activity the first one after the focused window, in
+// creation order, is picked.  Nothing happens if there is no activity.
+func FocusNextActive() {
+	Queue(func() {
+		ws := sortedWindows()
+		start := 0
+		for i, w := range ws {
+			if w == focus {
+				start = i + 1
+			}
+		}
+
+		var next *Window
+		for i := range ws {
+			w := ws[(start+i)%len(ws)]
+			if next == nil || w.activity > next.activity {
+				next = w
+			}
+		}
+		if next == nil || next.activity == ActivityNone {
+			return
+		}
+		focusWindow(next)
+	})
+}
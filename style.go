@@ -0,0 +1,85 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import "github.com/gdamore/tcell/termbox"
+
+// Color is a terminal color.  The zero value is the terminal default color.
+type Color int
+
+// Colors.
+const (
+	ColorDefault Color = iota
+	ColorBlack
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorMagenta
+	ColorCyan
+	ColorWhite
+)
+
+// StyleFlag is a set of text attributes such as bold or underline.
+type StyleFlag uint8
+
+// Style flags.
+const (
+	StyleBold StyleFlag = 1 << iota
+	StyleUnderline
+	StyleReverse
+)
+
+// Style represents the way text is drawn: text color, background color and
+// attributes such as bold.  The zero value draws in the terminal default
+// colors.
+type Style struct {
+	Fg    Color     // foreground
+	Bg    Color     // background
+	Flags StyleFlag // bold, underline etc
+}
+
+// Reverse returns the style with foreground and background swapped.
+func (s Style) Reverse() Style {
+	s.Fg, s.Bg = s.Bg, s.Fg
+	return s
+}
+
+// StyleFromTermbox converts termbox foreground and background attributes to
+// a Style.  It is provided to ease migrating applications off termbox.
+func StyleFromTermbox(fg, bg termbox.Attribute) Style {
+	s := Style{
+		Fg: Color(fg & 0x1ff),
+		Bg: Color(bg & 0x1ff),
+	}
+	if (fg|bg)&termbox.AttrBold != 0 {
+		s.Flags |= StyleBold
+	}
+	if (fg|bg)&termbox.AttrUnderline != 0 {
+		s.Flags |= StyleUnderline
+	}
+	if (fg|bg)&termbox.AttrReverse != 0 {
+		s.Flags |= StyleReverse
+	}
+	return s
+}
+
+// Termbox returns the termbox foreground and background attributes that
+// correspond to the style.
+func (s Style) Termbox() (termbox.Attribute, termbox.Attribute) {
+	// ttk colors share their values with termbox colors
+	fg := termbox.Attribute(s.Fg)
+	bg := termbox.Attribute(s.Bg)
+	if s.Flags&StyleBold != 0 {
+		fg |= termbox.AttrBold
+	}
+	if s.Flags&StyleUnderline != 0 {
+		fg |= termbox.AttrUnderline
+	}
+	if s.Flags&StyleReverse != 0 {
+		fg |= termbox.AttrReverse
+	}
+	return fg, bg
+}
@@ -0,0 +1,480 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/terminfo"
+)
+
+// escDelay is how long a lone escape waits for the rest of an escape sequence
+// before it is reported as a key stroke.
+const escDelay = 50 * time.Millisecond
+
+var (
+	_ backend = (*Terminal)(nil) // ensure interface is satisfied
+)
+
+// Terminal drives a terminal over an arbitrary io.ReadWriter such as a pty,
+// an SSH channel or a TCP connection.  The terminal on the other end is
+// described by its terminfo name and is expected to already be in raw mode,
+// NewTerminalTTY takes care of that for a terminal device.  Window size
+// changes are not detected automatically; they must be reported with Resize
+// or SyncSize.
+//
+// A Terminal is handed to InitTerminal instead of calling Init.  Deinit
+// restores the terminal but does not close the io.ReadWriter, that is left to
+// the caller.  A Terminal can not be reused after Deinit.  Only one terminal,
+// either a Terminal or the controlling terminal, can be driven at a time.
+type Terminal struct {
+	rw      io.ReadWriter
+	ti      *terminfo.Terminfo
+	keys    map[string]Key // escape sequences to keys
+	shapes  bool           // terminal understands cursor shapes
+	fd      int            // terminal device, -1 if rw is not one
+	restore func()         // restores the device mode, may be nil
+
+	mtx    sync.Mutex // protects width and height
+	width  int
+	height int
+
+	resizeC chan struct{} // window size changed
+	inC     chan []byte   // raw input
+	eof     chan struct{} // closed when reading fails
+	readErr error         // valid once eof is closed
+	done    chan struct{} // closed on close
+	in      []byte        // unparsed input, only used by pollEvent
+
+	// output state, only used from queue context
+	out     bytes.Buffer // pending output
+	x       int          // terminal cursor x, -1 if unknown
+	y       int          // terminal cursor y
+	style   Style        // current terminal style
+	styleOK bool         // true if style is known
+	cursorX int          // cursor x requested by setCursor
+	cursorY int          // cursor y requested by setCursor
+	shown   bool         // true if the terminal cursor is visible
+	shape   CursorShape  // current cursor shape
+}
+
+// NewTerminal returns a Terminal that drives rw.  The term argument is the
+// terminfo name of the remote terminal, e.g. the TERM variable of an SSH pty
+// request, and width and height are its initial size.
+func NewTerminal(rw io.ReadWriter, term string, width, height int) (*Terminal,
+	error) {
+
+	ti, err := terminfo.LookupTerminfo(term)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Terminal{
+		rw:      rw,
+		ti:      ti,
+		shapes:  hasCursorShapes(term),
+		keys:    make(map[string]Key),
+		width:   width,
+		height:  height,
+		resizeC: make(chan struct{}, 1),
+		inC:     make(chan []byte),
+		eof:     make(chan struct{}),
+		done:    make(chan struct{}),
+		fd:      -1,
+		x:       -1,
+		cursorX: -1,
+		cursorY: -1,
+	}
+	t.prepareKeys()
+
+	return t, nil
+}
+
+// prepareKeys fills the escape sequence lookup table.
+func (t *Terminal) prepareKeys() {
+	ti := t.ti
+	add := func(seq string, kc KeyCode, mod Modifier) {
+		if len(seq) < 2 {
+			// single bytes are handled as control characters
+			return
+		}
+		if _, found := t.keys[seq]; found {
+			return
+		}
+		t.keys[seq] = Key{Key: kc, Mod: mod}
+	}
+
+	add(ti.KeyF1, KeyF1, 0)
+	add(ti.KeyF2, KeyF2, 0)
+	add(ti.KeyF3, KeyF3, 0)
+	add(ti.KeyF4, KeyF4, 0)
+	add(ti.KeyF5, KeyF5, 0)
+	add(ti.KeyF6, KeyF6, 0)
+	add(ti.KeyF7, KeyF7, 0)
+	add(ti.KeyF8, KeyF8, 0)
+	add(ti.KeyF9, KeyF9, 0)
+	add(ti.KeyF10, KeyF10, 0)
+	add(ti.KeyF11, KeyF11, 0)
+	add(ti.KeyF12, KeyF12, 0)
+	add(ti.KeyInsert, KeyInsert, 0)
+	add(ti.KeyDelete, KeyDelete, 0)
+	add(ti.KeyHome, KeyHome, 0)
+	add(ti.KeyEnd, KeyEnd, 0)
+	add(ti.KeyPgUp, KeyPgup, 0)
+	add(ti.KeyPgDn, KeyPgdn, 0)
+	add(ti.KeyUp, KeyArrowUp, 0)
+	add(ti.KeyDown, KeyArrowDown, 0)
+	add(ti.KeyLeft, KeyArrowLeft, 0)
+	add(ti.KeyRight, KeyArrowRight, 0)
+	add(ti.KeyBacktab, KeyBacktab, 0)
+
+	add(ti.KeyShfUp, KeyArrowUp, ModShift)
+	add(ti.KeyShfDown, KeyArrowDown, ModShift)
+	add(ti.KeyShfLeft, KeyArrowLeft, ModShift)
+	add(ti.KeyShfRight, KeyArrowRight, ModShift)
+	add(ti.KeyShfHome, KeyHome, ModShift)
+	add(ti.KeyShfEnd, KeyEnd, ModShift)
+	add(ti.KeyShfPgUp, KeyPgup, ModShift)
+	add(ti.KeyShfPgDn, KeyPgdn, ModShift)
+	add(ti.KeyCtrlUp, KeyArrowUp, ModCtrl)
+	add(ti.KeyCtrlDown, KeyArrowDown, ModCtrl)
+	add(ti.KeyCtrlLeft, KeyArrowLeft, ModCtrl)
+	add(ti.KeyCtrlRight, KeyArrowRight, ModCtrl)
+	add(ti.KeyCtrlHome, KeyHome, ModCtrl)
+	add(ti.KeyCtrlEnd, KeyEnd, ModCtrl)
+	add(ti.KeyAltUp, KeyArrowUp, ModAlt)
+	add(ti.KeyAltDown, KeyArrowDown, ModAlt)
+	add(ti.KeyAltLeft, KeyArrowLeft, ModAlt)
+	add(ti.KeyAltRight, KeyArrowRight, ModAlt)
+	add(ti.KeyAltHome, KeyHome, ModAlt)
+	add(ti.KeyAltEnd, KeyEnd, ModAlt)
+	add(ti.KeyMetaUp, KeyArrowUp, ModAlt)
+	add(ti.KeyMetaDown, KeyArrowDown, ModAlt)
+	add(ti.KeyMetaLeft, KeyArrowLeft, ModAlt)
+	add(ti.KeyMetaRight, KeyArrowRight, ModAlt)
+	add(ti.KeyCtrlShfUp, KeyArrowUp, ModCtrl|ModShift)
+	add(ti.KeyCtrlShfDown, KeyArrowDown, ModCtrl|ModShift)
+	add(ti.KeyCtrlShfLeft, KeyArrowLeft, ModCtrl|ModShift)
+	add(ti.KeyCtrlShfRight, KeyArrowRight, ModCtrl|ModShift)
+	add(ti.KeyAltShfUp, KeyArrowUp, ModAlt|ModShift)
+	add(ti.KeyAltShfDown, KeyArrowDown, ModAlt|ModShift)
+	add(ti.KeyAltShfLeft, KeyArrowLeft, ModAlt|ModShift)
+	add(ti.KeyAltShfRight, KeyArrowRight, ModAlt|ModShift)
+
+	// cursor keys in normal mode, in case the terminal ignores keypad
+	// transmit mode
+	add("\x1b[A", KeyArrowUp, 0)
+	add("\x1b[B", KeyArrowDown, 0)
+	add("\x1b[C", KeyArrowRight, 0)
+	add("\x1b[D", KeyArrowLeft, 0)
+	add("\x1b[H", KeyHome, 0)
+	add("\x1b[F", KeyEnd, 0)
+	add("\x1bOA", KeyArrowUp, 0)
+	add("\x1bOB", KeyArrowDown, 0)
+	add("\x1bOC", KeyArrowRight, 0)
+	add("\x1bOD", KeyArrowLeft, 0)
+	add("\x1bOH", KeyHome, 0)
+	add("\x1bOF", KeyEnd, 0)
+	add("\x1b[1~", KeyHome, 0)
+	add("\x1b[4~", KeyEnd, 0)
+	add("\x1b[Z", KeyBacktab, 0)
+}
+
+// Resize reports a new window size, e.g. from an SSH window-change request.
+// Resize may be called from any go routine.
+func (t *Terminal) Resize(width, height int) {
+	t.mtx.Lock()
+	t.width = width
+	t.height = height
+	t.mtx.Unlock()
+
+	select {
+	case t.resizeC <- struct{}{}:
+	default:
+		// resize already pending
+	}
+}
+
+// Done returns a channel that is closed once reading from the underlying
+// io.ReadWriter fails, typically because the remote end hung up.
+func (t *Terminal) Done() <-chan struct{} {
+	return t.eof
+}
+
+// Err returns the error that closed the Done channel.
+func (t *Terminal) Err() error {
+	select {
+	case <-t.eof:
+		return t.readErr
+	default:
+		return nil
+	}
+}
+
+// reader moves raw input to pollEvent.  Must be called as a go routine.
+func (t *Terminal) reader() {
+	for {
+		buf := make([]byte, 256)
+		n, err := t.rw.Read(buf)
+		if n > 0 {
+			select {
+			case t.inC <- buf[:n]:
+			case <-t.done:
+				return
+			}
+		}
+		if err != nil {
+			t.readErr = err
+			close(t.eof)
+			return
+		}
+	}
+}
+
+// puts emits a terminfo string.
+func (t *Terminal) puts(s string) {
+	t.ti.TPuts(&t.out, s)
+}
+
+func (t *Terminal) init() error {
+	go t.reader()
+
+	t.puts(t.ti.EnterCA)
+	t.puts(t.ti.EnterKeypad)
+	t.puts(t.ti.HideCursor)
+	t.puts(t.ti.AttrOff)
+	t.puts(t.ti.Clear)
+	t.x = -1
+	t.styleOK = false
+	t.shown = false
+
+	_, err := t.flush()
+	return err
+}
+
+func (t *Terminal) close() {
+	if t.shape != CursorDefault {
+		t.puts(decscusr(CursorDefault))
+	}
+	t.puts(t.ti.ExitKeypad)
+	t.puts(t.ti.AttrOff)
+	t.puts(t.ti.ShowCursor)
+	t.puts(t.ti.Clear)
+	t.puts(t.ti.ExitCA)
+	_, _ = t.rw.Write(t.out.Bytes())
+	t.out.Reset()
+	if t.restore != nil {
+		t.restore()
+	}
+	close(t.done)
+}
+
+func (t *Terminal) size() (int, int) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.width, t.height
+}
+
+func (t *Terminal) clear(s Style) {
+	t.sendStyle(Style{Bg: s.Bg})
+	t.puts(t.ti.Clear)
+	t.x = -1
+}
+
+// sendStyle switches the terminal to the provided style if needed.
+func (t *Terminal) sendStyle(s Style) {
+	if t.styleOK && t.style == s {
+		return
+	}
+
+	t.puts(t.ti.AttrOff)
+	if s.Flags&StyleBold != 0 {
+		t.puts(t.ti.Bold)
+	}
+	if s.Flags&StyleUnderline != 0 {
+		t.puts(t.ti.Underline)
+	}
+	if s.Flags&StyleReverse != 0 {
+		t.puts(t.ti.Reverse)
+	}
+	fi, bi := -1, -1
+	if s.Fg != ColorDefault {
+		fi = int(s.Fg - ColorBlack)
+	}
+	if s.Bg != ColorDefault {
+		bi = int(s.Bg - ColorBlack)
+	}
+	t.puts(t.ti.TColor(fi, bi))
+
+	t.style = s
+	t.styleOK = true
+}
+
+func (t *Terminal) setCell(x, y int, c Cell) {
+	width, height := t.size()
+	if x < 0 || y < 0 || x >= width || y >= height {
+		return
+	}
+
+	if t.x != x || t.y != y {
+		t.puts(t.ti.TGoto(x, y))
+	}
+	t.sendStyle(c.Style)
+	ch := c.Ch
+	if ch < ' ' {
+		ch = ' '
+	}
+	t.out.WriteRune(ch)
+
+	t.x = x + 1
+	t.y = y
+	if t.x >= width {
+		// terminals differ in where the cursor ends up
+		t.x = -1
+	}
+}
+
+func (t *Terminal) setCursor(x, y int) {
+	t.cursorX = x
+	t.cursorY = y
+}
+
+func (t *Terminal) setCursorShape(s CursorShape) {
+	if s == t.shape || !t.shapes {
+		return
+	}
+	t.shape = s
+	t.puts(decscusr(s))
+}
+
+// flush sends the pending output.  The cursor is only moved, shown or hidden
+// if that changed so that a flush without changes sends nothing.
+func (t *Terminal) flush() (int, error) {
+	width, height := t.size()
+	if t.cursorX < 0 || t.cursorY < 0 || t.cursorX >= width ||
+		t.cursorY >= height {
+		if t.shown {
+			t.puts(t.ti.HideCursor)
+			t.shown = false
+		}
+	} else {
+		if t.x != t.cursorX || t.y != t.cursorY {
+			t.puts(t.ti.TGoto(t.cursorX, t.cursorY))
+			t.x = t.cursorX
+			t.y = t.cursorY
+		}
+		if !t.shown {
+			t.puts(t.ti.ShowCursor)
+			t.shown = true
+		}
+	}
+	if t.out.Len() == 0 {
+		return 0, nil
+	}
+
+	n, err := t.rw.Write(t.out.Bytes())
+	t.out.Reset()
+	return n, err
+}
+
+func (t *Terminal) pollEvent() event {
+	for {
+		var expire <-chan time.Time
+		if len(t.in) > 0 {
+			k, n := t.parse(t.in, true)
+			if n > 0 {
+				t.in = t.in[n:]
+				return event{typ: eventKey, key: k}
+			}
+
+			// wait a bit for the remainder of partial input
+			expire = time.After(escDelay)
+		}
+
+		select {
+		case b := <-t.inC:
+			t.in = append(t.in, b...)
+		case <-expire:
+			k, n := t.parse(t.in, false)
+			t.in = t.in[n:]
+			return event{typ: eventKey, key: k}
+		case <-t.resizeC:
+			return event{typ: eventResize}
+		case <-t.eof:
+			return event{typ: eventError, err: t.readErr}
+		case <-t.done:
+			return event{typ: eventError, err: errBackendClosed}
+		}
+	}
+}
+
+// parse decodes the first key in b and returns it together with the number of
+// bytes consumed.  If b holds a partial key and wait is true, parse consumes
+// nothing so that the caller can wait for more input.
+func (t *Terminal) parse(b []byte, wait bool) (Key, int) {
+	if b[0] != 0x1b {
+		return parseRune(b, wait)
+	}
+
+	// longest known escape sequence wins
+	var (
+		k     Key
+		n     int
+		wants bool
+	)
+	for seq, v := range t.keys {
+		if strings.HasPrefix(string(b), seq) {
+			if len(seq) > n {
+				k = v
+				n = len(seq)
+			}
+		} else if len(seq) > len(b) && strings.HasPrefix(seq, string(b)) {
+			wants = true
+		}
+	}
+	if n > 0 {
+		return k, n
+	}
+	if wait && (wants || len(b) == 1) {
+		return Key{}, 0
+	}
+	if len(b) == 1 {
+		return Key{Key: KeyEsc}, 1
+	}
+
+	// escape followed by a key means alt
+	k, n = t.parse(b[1:], wait)
+	if n == 0 {
+		return Key{}, 0
+	}
+	k.Mod |= ModAlt
+	return k, n + 1
+}
+
+// parseRune decodes a control character or UTF-8 encoded rune.
+func parseRune(b []byte, wait bool) (Key, int) {
+	switch c := b[0]; {
+	case c < ' ' || c == 0x7f:
+		return Key{Key: KeyCode(c)}, 1
+	case c == ' ':
+		return Key{Key: KeySpace, Ch: ' '}, 1
+	case c < utf8.RuneSelf:
+		return Key{Ch: rune(c)}, 1
+	}
+
+	if !utf8.FullRune(b) {
+		if wait {
+			return Key{}, 0
+		}
+		return Key{Ch: utf8.RuneError}, 1
+	}
+	r, n := utf8.DecodeRune(b)
+	return Key{Ch: r}, n
+}
@@ -0,0 +1,342 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// remote is the far end of a Terminal connection.
+type remote struct {
+	conn io.ReadWriter
+	mtx  sync.Mutex
+	out  bytes.Buffer
+}
+
+func newRemote(conn io.ReadWriter) *remote {
+	r := &remote{conn: conn}
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := conn.Read(buf)
+			r.mtx.Lock()
+			r.out.Write(buf[:n])
+			r.mtx.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// write types s on the remote terminal.
+func (r *remote) write(t *testing.T, s string) {
+	if _, err := r.conn.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// typeKey types s on the remote terminal and checks that the application
+// receives key.
+func (r *remote) typeKey(t *testing.T, s string, key Key) {
+	r.write(t, s)
+	select {
+	case k := <-KeyChannel():
+		k.Window = nil
+		if k != key {
+			t.Fatalf("%q: got %+v want %+v", s, k, key)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("%q: timeout", s)
+	}
+}
+
+// waitFor waits until s was written to the remote terminal.
+func (r *remote) waitFor(t *testing.T, s string) {
+	for i := 0; i < 100; i++ {
+		r.mtx.Lock()
+		found := strings.Contains(r.out.String(), s)
+		r.mtx.Unlock()
+		if found {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%q never displayed", s)
+}
+
+// waitQueue waits until f, which is called from queue context, returns true.
+func waitQueue(t *testing.T, what string, f func() bool) {
+	c := make(chan bool)
+	for i := 0; i < 100; i++ {
+		Queue(func() { c <- f() })
+		if <-c {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%v never happened", what)
+}
+
+// funcWindow is a window whose widgets are added by init.
+type funcWindow struct {
+	init func(w *Window)
+}
+
+func (fw *funcWindow) Init(w *Window) {
+	if fw.init != nil {
+		fw.init(w)
+	}
+}
+
+func (fw *funcWindow) Render(w *Window) {}
+
+func (fw *funcWindow) KeyHandler(w *Window, k Key) {}
+
+// helloWorld adds the label that most tests look for.
+func helloWorld(w *Window) {
+	w.AddLabel(1, 1, "hello world")
+}
+
+// addEdit returns an init function that adds an edit at the top left of the
+// window and stores it in e.
+func addEdit(e **Edit, width int, text string) func(w *Window) {
+	return func(w *Window) {
+		*e = w.AddEditText(0, 0, width, text)
+	}
+}
+
+// session is a Terminal that is driven over a pipe.
+type session struct {
+	*remote
+	term   *Terminal
+	window *Window // focused when the session started
+	local  net.Conn
+	far    net.Conn
+}
+
+// startSession drives a 40x10 xterm over a pipe and focuses a new window whose
+// widgets are added by init.  The window is announced instead of painted if
+// linear is set.  The session must be closed.
+func startSession(t *testing.T, linear bool, init func(w *Window)) *session {
+	local, far := net.Pipe()
+	s := &session{remote: newRemote(far), local: local, far: far}
+	var err error
+	s.term, err = NewTerminal(local, "xterm", 40, 10)
+	if err != nil {
+		s.close()
+		t.Fatal(err)
+	}
+	if err = InitTerminal(s.term); err != nil {
+		s.close()
+		t.Fatal(err)
+	}
+	if linear {
+		SetLinear(local)
+	}
+	s.window = NewWindow(&funcWindow{init: init})
+	Focus(s.window)
+	return s
+}
+
+// close deinits ttk and closes the pipe.
+func (s *session) close() {
+	Deinit()
+	s.local.Close()
+	s.far.Close()
+}
+
+func TestTerminal(t *testing.T) {
+	s := startSession(t, false, helloWorld)
+	defer s.close()
+
+	// a second session does not take over
+	local2, far2 := net.Pipe()
+	defer local2.Close()
+	defer far2.Close()
+	r2 := newRemote(far2)
+	term2, err := NewTerminal(local2, "xterm", 40, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = InitTerminal(term2); err != ErrAlreadyInitialized {
+		t.Fatalf("second session: got %v", err)
+	}
+
+	s.waitFor(t, "hello")
+	s.waitFor(t, "world")
+
+	// nothing changed so nothing should be sent
+	Flush()
+	last, total := Stats()
+	if last.Cells != 0 || last.Bytes != 0 {
+		t.Fatalf("sent without changes: %v cells %v bytes", last.Cells,
+			last.Bytes)
+	}
+	if total.Cells != len("helloworld") {
+		t.Fatalf("total cells: got %v want %v", total.Cells,
+			len("helloworld"))
+	}
+
+	tests := []struct {
+		in  string
+		key Key
+	}{
+		{"x", Key{Ch: 'x'}},
+		{"\x1b[A", Key{Key: KeyArrowUp}},
+		{"\x1bOD", Key{Key: KeyArrowLeft}},
+		{"\x1bx", Key{Mod: ModAlt, Ch: 'x'}},
+		{"\x01", Key{Key: KeyCtrlA}},
+		{"\x1b", Key{Key: KeyEsc}},
+		{"é", Key{Ch: 'é'}},
+	}
+	for _, test := range tests {
+		s.typeKey(t, test.in, test.key)
+	}
+
+	// grow the terminal and make sure we render again
+	s.mtx.Lock()
+	s.out.Reset()
+	s.mtx.Unlock()
+	s.term.Resize(60, 12)
+	s.waitFor(t, "hello")
+
+	r2.mtx.Lock()
+	defer r2.mtx.Unlock()
+	if r2.out.Len() != 0 {
+		t.Fatalf("second session written: %q", r2.out.String())
+	}
+}
+
+func TestLinear(t *testing.T) {
+	var (
+		list   *List
+		status *Label
+	)
+	s := startSession(t, true, func(w *Window) {
+		w.SetTitle("chat")
+		list = w.AddList(0, 0, 0, -2)
+		status = w.AddStatus(-2, JustifyLeft, "idle")
+		w.AddEditText(0, -1, 0, "hi").SetName("nick")
+		w.AddEditText(10, -1, 0, "").SetName("message")
+	})
+	defer s.close()
+	s.waitFor(t, "window: chat\r\nedit: nick, contents hi\r\n")
+
+	bold, err := Escape(AttrBold, ColorDefault, ColorDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Queue(func() {
+		list.Append("hello %vthere", bold)
+		status.SetText("busy")
+	})
+	s.waitFor(t, "hello there\r\n")
+
+	// status labels are only read on demand
+	s.window.ReadStatus()
+	s.waitFor(t, "status: busy\r\n")
+
+	// focus changes are announced
+	s.write(t, "\t")
+	s.waitFor(t, "edit: message, empty\r\n")
+
+	// cells were never painted
+	s.mtx.Lock()
+	out := s.out.String()
+	s.mtx.Unlock()
+	if strings.Contains(out, "idle") {
+		t.Fatalf("cells painted in linear mode: %q", out)
+	}
+}
+
+func TestCursorShape(t *testing.T) {
+	for term, want := range map[string]bool{
+		"xterm-256color": true,
+		"tmux-256color":  true,
+		"linux":          false,
+		"vt100":          false,
+	} {
+		if hasCursorShapes(term) != want {
+			t.Fatalf("%v: expected %v", term, want)
+		}
+	}
+
+	var e *Edit
+	s := startSession(t, false, addEdit(&e, 10, "abc"))
+	defer s.close()
+	s.waitFor(t, "\x1b[6 q") // bar
+
+	// a visible cursor that did not move is not sent again
+	Flush()
+	if last, _ := Stats(); last.Bytes != 0 {
+		t.Fatalf("cursor sent without changes: %v bytes", last.Bytes)
+	}
+
+	// Insert toggles overwrite mode
+	s.write(t, "\x1b[2~")
+	s.waitFor(t, "\x1b[2 q") // block
+	s.write(t, "\x1b[Dx")
+	waitQueue(t, "overwrite", func() bool { return e.GetText() == "abx" })
+
+	// the default shape is restored on exit
+	Deinit()
+	s.waitFor(t, "\x1b[0 q")
+}
+
+func TestCompletion(t *testing.T) {
+	var cmd, nick *Edit
+	s := startSession(t, false, func(w *Window) {
+		commands := []string{"/join", "/jump", "/quit"}
+		cmd = w.AddEditText(0, 0, 20, "")
+		cmd.SetCompleter(CompleterFunc(func(text string, pos int) []string {
+			var cands []string
+			for _, c := range commands {
+				if strings.HasPrefix(c, text[:pos]) {
+					cands = append(cands, c)
+				}
+			}
+			return cands
+		}), CompletePopup)
+		nick = w.AddEditText(0, 5, 20, "")
+	})
+	defer s.close()
+	w := s.window
+
+	// several candidates open a popup under the edit
+	s.write(t, "/j\t")
+	s.waitFor(t, "/jump")
+	waitQueue(t, "popup", func() bool {
+		return len(overlays) == 1 && overlays[0].originY == 1
+	})
+
+	// down selects the next candidate, enter inserts it
+	s.write(t, "\x1b[B\r")
+	waitQueue(t, "insert", func() bool {
+		return len(overlays) == 0 && cmd.GetText() == "/jump"
+	})
+
+	// a single candidate is inserted directly
+	s.write(t, "\x7f\x7f\x7f\x7fq\t")
+	waitQueue(t, "single", func() bool { return cmd.GetText() == "/quit" })
+
+	// without a completer tab moves focus
+	w.SetFocus(nick)
+	s.write(t, "\t")
+	waitQueue(t, "focus", func() bool { return w.focusedWidget() == cmd })
+
+	// switching windows closes the popup
+	s.write(t, "\x15/j\t")
+	waitQueue(t, "popup", func() bool { return len(overlays) == 1 })
+	Focus(NewWindow(&funcWindow{}))
+	waitQueue(t, "close", func() bool { return len(overlays) == 0 })
+}
@@ -0,0 +1,586 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WidgetTextArea uniquely identifies the text area widget.
+const (
+	WidgetTextArea = "textarea"
+)
+
+var (
+	_ Widgeter = (*TextArea)(nil) // ensure interface is satisfied
+)
+
+// TextArea actions.  They are bound in the WidgetTextArea keymap together with
+// the Edit actions, which act on the line the cursor is on.
+const (
+	ActionTextAreaUp       = "textarea.up"       // cursor one line up
+	ActionTextAreaDown     = "textarea.down"     // cursor one line down
+	ActionTextAreaPageUp   = "textarea.pageup"   // cursor one page up
+	ActionTextAreaPageDown = "textarea.pagedown" // cursor one page down
+	ActionTextAreaNewline  = "textarea.newline"  // insert line break
+)
+
+// defaultSubmitKey submits the text of a TextArea.
+const defaultSubmitKey = "Alt-Enter"
+
+// init registers the TextArea Widget and its default key bindings.
+func init() {
+	registeredWidgets[WidgetTextArea] = NewTextArea
+
+	km := WidgetKeymap(WidgetTextArea)
+	for _, b := range []struct {
+		keys   string
+		action string
+	}{
+		{"Ctrl-A", ActionEditHome},
+		{"Home", ActionEditHome},
+		{"Ctrl-E", ActionEditEnd},
+		{"End", ActionEditEnd},
+		{"Ctrl-U", ActionEditKillLine},
+		{"Left", ActionEditLeft},
+		{"Right", ActionEditRight},
+		{"Up", ActionTextAreaUp},
+		{"Down", ActionTextAreaDown},
+		{"PgUp", ActionTextAreaPageUp},
+		{"PgDn", ActionTextAreaPageDown},
+		{"Delete", ActionEditDelete},
+		{"Ctrl-H", ActionEditBackspace},
+		{"Backspace", ActionEditBackspace},
+		{"Enter", ActionTextAreaNewline},
+		{defaultSubmitKey, ActionEditSubmit},
+		{"Esc", ActionEditCancel},
+		{"Insert", ActionEditOverwrite},
+	} {
+		if err := km.Bind(b.keys, b.action); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// textLine is a visual line of a TextArea.
+type textLine struct {
+	start   int  // index of first rune
+	end     int  // index after last rune, excluding line break
+	wrapped bool // line continues on the next visual line
+}
+
+// TextArea is a multi line edit widget.  Long lines are soft wrapped at word
+// boundaries and the text scrolls vertically.  Line breaks are inserted with
+// Enter and the text is submitted with Alt-Enter, see SetSubmitKey.
+type TextArea struct {
+	Widget
+	trueX      int        // actual x coordinate
+	trueY      int        // actual y coordinate
+	trueW      int        // actual width
+	trueH      int        // actual height
+	width      int        // prefered widget width
+	height     int        // prefered widget height
+	text       []rune     // text as runes
+	lines      []textLine // visual lines, set by wrap
+	pos        int        // cursor index in text
+	goal       int        // column vertical movement aims for, -1 if unset
+	top        int        // first displayed line
+	submitKeys string     // keys bound to submit
+	visibility Visibility
+	style      Style
+	overwrite  bool        // typed runes replace the rune under the cursor
+	insertC    CursorShape // cursor shape in insert mode
+	overwriteC CursorShape // cursor shape in overwrite mode
+
+	// callbacks, called from queue context
+	onChange func(string)
+	onSubmit func(string) bool
+	onCancel func()
+}
+
+// NewTextArea is the TextArea initializer.  This call implements the NewWidget
+// convention by taking a *Window and and an anchor point to render the widget.
+func NewTextArea(w *Window, x, y int) (Widgeter, error) {
+	return &TextArea{
+		Widget:     MakeWidget(w, x, y),
+		goal:       -1,
+		submitKeys: defaultSubmitKey,
+		insertC:    CursorBar,
+		overwriteC: CursorBlock,
+	}, nil
+}
+
+// AddTextArea is a convenience function to add a new text area with the
+// provided text to a window.  Width and height follow the same rules as they
+// do for AddList.  It wraps the AddWidget call.  AddTextArea must be called
+// from queue.
+func (w *Window) AddTextArea(x, y, width, height int, text string) *TextArea {
+	// we can ignore error for builtins
+	widget, _ := w.AddWidget(WidgetTextArea, x, y)
+	t := widget.(*TextArea)
+	t.width = width
+	t.height = height
+	t.style = defaultStyle().Reverse()
+	t.text = []rune(text)
+	t.Resize()
+	return t
+}
+
+func (t *TextArea) Visibility(op Visibility) Visibility {
+	switch op {
+	case VisibilityGet:
+		return t.visibility
+	case VisibilityShow:
+		t.visibility = op
+		t.Render()
+	case VisibilityHide:
+		t.visibility = op
+		t.clear()
+	}
+
+	return t.visibility
+}
+
+func (t *TextArea) clear() {
+	for y := 0; y < t.trueH; y++ {
+		t.w.printf(t.trueX, t.trueY+y, defaultStyle(),
+			strings.Repeat(" ", t.trueW))
+	}
+}
+
+// wrap splits the text into visual lines.  Lines are broken after the last
+// space that fits or, if there is none, at the width.  A line that fills the
+// entire width is followed by an empty line so that the cursor has a place
+// behind it.
+func (t *TextArea) wrap() {
+	width := t.trueW
+	if width < 1 {
+		width = 1
+	}
+
+	t.lines = t.lines[:0]
+	for start := 0; start <= len(t.text); {
+		end := start
+		for end < len(t.text) && t.text[end] != '\n' {
+			end++
+		}
+
+		s := start
+		for end-s > width {
+			b := s + width
+			for i := b - 1; i > s; i-- {
+				if t.text[i] == ' ' {
+					b = i + 1
+					break
+				}
+			}
+			t.lines = append(t.lines, textLine{start: s, end: b,
+				wrapped: true})
+			s = b
+		}
+		if end-s == width {
+			t.lines = append(t.lines, textLine{start: s, end: end,
+				wrapped: true})
+			s = end
+		}
+		t.lines = append(t.lines, textLine{start: s, end: end})
+
+		start = end + 1
+	}
+}
+
+// row returns the visual line the cursor is on.
+func (t *TextArea) row() int {
+	row := 0
+	for i, l := range t.lines {
+		if l.start > t.pos {
+			break
+		}
+		row = i
+	}
+	return row
+}
+
+// maxCol returns the last column the cursor can be placed on in a line.
+// The end of a wrapped line is the start of the next one.
+func (t *TextArea) maxCol(row int) int {
+	l := t.lines[row]
+	if l.wrapped {
+		return l.end - l.start - 1
+	}
+	return l.end - l.start
+}
+
+// scroll makes sure the cursor line is displayed.
+func (t *TextArea) scroll() {
+	row := t.row()
+	if row < t.top {
+		t.top = row
+	}
+	if t.trueH > 0 && row >= t.top+t.trueH {
+		t.top = row - t.trueH + 1
+	}
+	if t.top > len(t.lines)-1 {
+		t.top = len(t.lines) - 1
+	}
+	if t.top < 0 {
+		t.top = 0
+	}
+}
+
+// Render implements the Render interface.  This is called from queue context
+// so be careful to not use blocking calls.
+func (t *TextArea) Render() {
+	if t.visibility == VisibilityHide {
+		t.clear()
+		return
+	}
+
+	for y := 0; y < t.trueH; y++ {
+		n := 0
+		if row := t.top + y; row < len(t.lines) {
+			l := t.lines[row]
+			n = t.w.print(t.trueX, t.trueY+y, t.trueW, t.style,
+				string(t.text[l.start:l.end]))
+		}
+		// print the filler separately so that it is not reordered
+		t.w.print(t.trueX+n, t.trueY+y, t.trueW-n, t.style,
+			strings.Repeat(" ", t.trueW-n))
+	}
+}
+
+// setCursor places the cursor in front of the rune at pos.
+// setCursor shall be called from queue context.
+func (t *TextArea) setCursor() {
+	if len(t.lines) == 0 {
+		// not wrapped yet
+		return
+	}
+	row := t.row()
+	if row < t.top || row >= t.top+t.trueH {
+		// not displayed, i.e. there is no room for the text area
+		t.w.setCursor(-1, -1) // hide
+		return
+	}
+	l := t.lines[row]
+	col := bidiColumn(t.text[l.start:l.end], t.pos-l.start)
+	t.w.setCursor(t.trueX+col, t.trueY+row-t.top)
+	if t.overwrite {
+		t.w.setCursorShape(t.overwriteC)
+	} else {
+		t.w.setCursorShape(t.insertC)
+	}
+}
+
+// page returns the number of lines that PageUp and PageDown move.  One line
+// stays visible unless the text area is too small for that.
+func (t *TextArea) page() int {
+	if t.trueH > 1 {
+		return t.trueH - 1
+	}
+	return 1
+}
+
+// moveTo moves the cursor to the column of the goal on the provided line.
+func (t *TextArea) moveTo(row int) {
+	if row < 0 {
+		row = 0
+	}
+	if row > len(t.lines)-1 {
+		row = len(t.lines) - 1
+	}
+	if t.goal < 0 {
+		t.goal = t.pos - t.lines[t.row()].start
+	}
+	col := t.goal
+	if max := t.maxCol(row); col > max {
+		col = max
+	}
+	t.pos = t.lines[row].start + col
+}
+
+// edited rewraps the text after a change.
+func (t *TextArea) edited() {
+	t.goal = -1
+	t.wrap()
+	t.scroll()
+	t.Render()
+	t.setCursor()
+	if t.onChange != nil {
+		t.onChange(string(t.text))
+	}
+}
+
+// moved displays the cursor after it moved.
+func (t *TextArea) moved() {
+	top := t.top
+	t.scroll()
+	if top != t.top {
+		t.Render()
+	}
+	t.setCursor()
+}
+
+// KeyHandler implements the interface.  This is called from queue context
+// so be careful to not use blocking calls.
+func (t *TextArea) KeyHandler(ev Key) bool {
+	if len(t.lines) == 0 {
+		t.wrap()
+	}
+	vertical := false
+	switch ev.Action {
+	case ActionEditHome:
+		t.pos = t.lines[t.row()].start
+	case ActionEditEnd:
+		row := t.row()
+		t.pos = t.lines[row].start + t.maxCol(row)
+	case ActionEditLeft:
+		if t.pos > 0 {
+			t.pos--
+		}
+	case ActionEditRight:
+		if t.pos < len(t.text) {
+			t.pos++
+		}
+	case ActionTextAreaUp:
+		t.moveTo(t.row() - 1)
+		vertical = true
+	case ActionTextAreaDown:
+		t.moveTo(t.row() + 1)
+		vertical = true
+	case ActionTextAreaPageUp:
+		t.moveTo(t.row() - t.page())
+		vertical = true
+	case ActionTextAreaPageDown:
+		t.moveTo(t.row() + t.page())
+		vertical = true
+	case ActionEditKillLine:
+		t.killLine()
+		t.edited()
+		return true
+	case ActionEditDelete:
+		if t.pos < len(t.text) {
+			t.text = append(t.text[:t.pos], t.text[t.pos+1:]...)
+			t.edited()
+		}
+		return true
+	case ActionEditBackspace:
+		if t.pos > 0 {
+			t.text = append(t.text[:t.pos-1], t.text[t.pos:]...)
+			t.pos--
+			t.edited()
+		}
+		return true
+	case ActionTextAreaNewline:
+		t.text = insert(t.text, t.pos, '\n')
+		t.pos++
+		t.edited()
+		return true
+	case ActionEditOverwrite:
+		t.overwrite = !t.overwrite
+		t.setCursor()
+		return true
+	case ActionEditSubmit:
+		if t.onSubmit != nil {
+			return t.onSubmit(string(t.text))
+		}
+		// return false and let the application decide if it wants
+		// to consume the action
+		return false
+	case ActionEditCancel:
+		if t.onCancel != nil {
+			t.onCancel()
+			return true
+		}
+		return false
+	default:
+		return t.typed(ev)
+	}
+
+	if !vertical {
+		t.goal = -1
+	}
+	t.moved()
+	return true
+}
+
+// killLine erases the line the cursor is on up to, but not including, the
+// line break.  The other lines are left alone.
+func (t *TextArea) killLine() {
+	start := t.pos
+	for start > 0 && t.text[start-1] != '\n' {
+		start--
+	}
+	end := t.pos
+	for end < len(t.text) && t.text[end] != '\n' {
+		end++
+	}
+	t.text = append(t.text[:start], t.text[end:]...)
+	t.pos = start
+}
+
+// typed inserts, or overwrites, the rune of a key.
+func (t *TextArea) typed(ev Key) bool {
+	if ev.Key == KeySpace {
+		// use space
+		ev.Ch = ' '
+	}
+
+	// normal runes are displayed and stored
+	if ev.Ch != 0 && ev.Mod != 0 && ev.Key == 0 {
+		// forward special
+		return false
+	} else if ev.Ch == 0 {
+		return false
+	}
+
+	if t.overwrite && t.pos < len(t.text) && t.text[t.pos] != '\n' {
+		t.text[t.pos] = ev.Ch
+	} else {
+		t.text = insert(t.text, t.pos, ev.Ch)
+	}
+	t.pos++
+	t.edited()
+	return true
+}
+
+// position returns the window coordinates of the widget.
+func (t *TextArea) position() (int, int) {
+	return t.trueX, t.trueY
+}
+
+// CanFocus implements the interface.  This is called from queue context
+// so be careful to not use blocking calls.
+func (t *TextArea) CanFocus() bool {
+	return true // can focus
+}
+
+// Focus implements the interface.  This is called from queue context
+// so be careful to not use blocking calls.
+func (t *TextArea) Focus() {
+	t.setCursor()
+}
+
+// Resize implements the interface.  The text is wrapped to the new width.
+// This is called from queue context so be careful to not use blocking calls.
+func (t *TextArea) Resize() {
+	r := t.Bounds(t.width, t.height)
+	t.trueX = r.X
+	t.trueY = r.Y
+	t.trueW = r.Width
+	t.trueH = r.Height
+	t.wrap()
+	t.scroll()
+}
+
+// MinSize implements the MinSizer interface.  A text area requires at least
+// one line.
+func (t *TextArea) MinSize() (int, int) {
+	return t.MinBounds(t.width, t.height, 1, 1)
+}
+
+// Describe implements the Describer interface.
+func (t *TextArea) Describe() string {
+	if len(t.text) == 0 {
+		return fmt.Sprintf("text area: %v, empty", t.name)
+	}
+	return fmt.Sprintf("text area: %v, %v lines, contents %v", t.name,
+		strings.Count(string(t.text), "\n")+1, string(t.text))
+}
+
+// SetStyle sets the Style.  This will not be displayed immediately.
+// SetStyle shall be called from queue context.
+func (t *TextArea) SetStyle(s Style) {
+	t.style = s
+}
+
+// GetText returns the text.
+// GetText shall be called from queue context.
+func (t *TextArea) GetText() string {
+	return string(t.text)
+}
+
+// SetValue replaces the text and places the cursor at the end of the text if
+// end is set, otherwise at the beginning.  The OnChange callback is not
+// called.  This will not be displayed immediately.
+// SetValue shall be called from queue context.
+func (t *TextArea) SetValue(text string, end bool) {
+	t.text = []rune(text)
+	t.pos = 0
+	if end {
+		t.pos = len(t.text)
+	}
+	t.goal = -1
+	t.top = 0
+	t.wrap()
+	t.scroll()
+}
+
+// OnChange sets the function that is called with the text whenever the user
+// changes it.  The function is called from queue context so be careful to not
+// use blocking calls.
+// OnChange shall be called from queue context.
+func (t *TextArea) OnChange(f func(string)) {
+	t.onChange = f
+}
+
+// OnSubmit sets the function that is called with the text when the submit
+// key is pressed.  The key is consumed if f returns true, otherwise it is
+// forwarded to the application.
+// OnSubmit shall be called from queue context.
+func (t *TextArea) OnSubmit(f func(string) bool) {
+	t.onSubmit = f
+}
+
+// OnCancel sets the function that is called when Esc is pressed.  The key is
+// consumed when a function is set.
+// OnCancel shall be called from queue context.
+func (t *TextArea) OnCancel(f func()) {
+	t.onCancel = f
+}
+
+// SetSubmitKey binds keys, i.e. "Enter", to submit the text of this text area.
+// The keys that submitted the text before insert a line break instead, so
+// SetSubmitKey("Enter") swaps the meaning of Enter and Alt-Enter.
+// SetSubmitKey shall be called from queue context.
+func (t *TextArea) SetSubmitKey(keys string) error {
+	km := t.Keymap()
+	if err := km.Bind(keys, ActionEditSubmit); err != nil {
+		return err
+	}
+	if keys != t.submitKeys {
+		if err := km.Bind(t.submitKeys, ActionTextAreaNewline); err != nil {
+			return err
+		}
+	}
+	t.submitKeys = keys
+	return nil
+}
+
+// Overwrite returns true if the text area is in overwrite mode.
+// Overwrite shall be called from queue context.
+func (t *TextArea) Overwrite() bool {
+	return t.overwrite
+}
+
+// SetOverwrite switches between overwrite and insert mode.  In overwrite mode
+// typed runes replace the rune under the cursor, line breaks are never
+// replaced.  The Insert key toggles the mode.
+// SetOverwrite shall be called from queue context.
+func (t *TextArea) SetOverwrite(overwrite bool) {
+	t.overwrite = overwrite
+	if t.w.focusedWidget() == Widgeter(t) {
+		t.setCursor()
+	}
+}
+
+// SetCursorShapes sets the cursor shapes that are used in insert and in
+// overwrite mode.  The defaults are CursorBar and CursorBlock.
+// SetCursorShapes shall be called from queue context.
+func (t *TextArea) SetCursorShapes(insert, overwrite CursorShape) {
+	t.insertC = insert
+	t.overwriteC = overwrite
+}
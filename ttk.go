@@ -12,8 +12,6 @@ import (
 	"strings"
 	"sync"
 	"unicode/utf8"
-
-	"github.com/gdamore/tcell/termbox"
 )
 
 const (
@@ -21,20 +19,14 @@ const (
 	ANSIFg = 30
 	ANSIBg = 40
 
+	// ANSIDefault is added to ANSIFg or ANSIBg to select the default color.
+	ANSIDefault = 9
+
 	AttrNA        = -1
 	AttrReset     = 0
 	AttrBold      = 1
 	AttrUnderline = 3
 	AttrReverse   = 7
-
-	ColorBlack   = 0
-	ColorRed     = 1
-	ColorGreen   = 2
-	ColorYellow  = 3
-	ColorBlue    = 4
-	ColorMagenta = 5
-	ColorCyan    = 6
-	ColorWhite   = 7
 )
 
 var (
@@ -45,9 +37,10 @@ var (
 	ErrInvalidBackground = errors.New("invalid background")
 )
 
-// Color creates an ANSI compatible escape sequence that encodes colors and
-// attributes.
-func Color(at, fg, bg int) (string, error) {
+// Escape creates an ANSI compatible escape sequence that encodes colors and
+// attributes.  Pass AttrNA for any of the parameters that should remain
+// unchanged.
+func Escape(at int, fg, bg Color) (string, error) {
 	var a, f, b string
 
 	// can't be all NA
@@ -67,8 +60,10 @@ func Color(at, fg, bg int) (string, error) {
 	switch {
 	case fg == AttrNA:
 		break
+	case fg == ColorDefault:
+		f = fmt.Sprintf("%v;", ANSIDefault+ANSIFg)
 	case fg >= ColorBlack && fg <= ColorWhite:
-		f = fmt.Sprintf("%v;", fg+ANSIFg)
+		f = fmt.Sprintf("%v;", int(fg-ColorBlack)+ANSIFg)
 	default:
 		return "", ErrInvalidForeground
 	}
@@ -76,8 +71,10 @@ func Color(at, fg, bg int) (string, error) {
 	switch {
 	case bg == AttrNA:
 		break
+	case bg == ColorDefault:
+		b = fmt.Sprintf("%v;", ANSIDefault+ANSIBg)
 	case bg >= ColorBlack && bg <= ColorWhite:
-		b = fmt.Sprintf("%v;", bg+ANSIBg)
+		b = fmt.Sprintf("%v;", int(bg-ColorBlack)+ANSIBg)
 	default:
 		return "", ErrInvalidBackground
 	}
@@ -90,13 +87,35 @@ func Color(at, fg, bg int) (string, error) {
 	return es, nil
 }
 
+// ColorEscape creates an escape sequence like Escape but takes the ANSI color
+// numbers 0 (black) to 7 (white), as the Color function did before Color
+// became a type.  Pass AttrNA for any of the parameters that should remain
+// unchanged.
+//
+// Deprecated: use Escape with the Color constants.
+func ColorEscape(at, fg, bg int) (string, error) {
+	if fg != AttrNA && (fg < 0 || fg > 7) {
+		return "", ErrInvalidForeground
+	}
+	if bg != AttrNA && (bg < 0 || bg > 7) {
+		return "", ErrInvalidBackground
+	}
+	f, b := Color(fg), Color(bg)
+	if fg != AttrNA {
+		f += ColorBlack
+	}
+	if bg != AttrNA {
+		b += ColorBlack
+	}
+	return Escape(at, f, b)
+}
+
 // DecodeColor decodes an ANSI color escape sequence and ignores trailing
-// characters.  It returns an Attributs type that can be used directly in
-// termbox (note that the termbox colors are off by one).  The skip contains
-// the location of the next character that was not consumed by the escape
-// sequence.
-func DecodeColor(esc string) (*Attributes, int, error) {
-	var a Attributes
+// characters.  It returns the Style that the sequence selects.  The skip
+// contains the location of the next character that was not consumed by the
+// escape sequence.
+func DecodeColor(esc string) (*Style, int, error) {
+	var a Style
 
 	if len(esc) < 2 || !strings.HasPrefix(esc, "\x1b[") {
 		return nil, 0, ErrNotEscSequence
@@ -122,20 +141,21 @@ func DecodeColor(esc string) (*Attributes, int, error) {
 		switch {
 		case n == AttrReset:
 			// return defaults
-			a.Fg = fg
-			a.Bg = bg
+			a = defaultStyle()
 		case n == AttrBold:
-			a.Fg |= termbox.AttrBold
+			a.Flags |= StyleBold
 		case n == AttrUnderline:
-			a.Fg |= termbox.AttrUnderline
+			a.Flags |= StyleUnderline
 		case n == AttrReverse:
-			a.Fg |= termbox.AttrReverse
-		case n >= ColorBlack+ANSIFg && n <= ColorWhite+ANSIFg:
-			// note that termbox colors are off by one
-			a.Fg |= termbox.Attribute(n - ANSIFg + 1)
-		case n >= ColorBlack+ANSIBg && n <= ColorWhite+ANSIBg:
-			// note that termbox colors are off by one
-			a.Bg |= termbox.Attribute(n - ANSIBg + 1)
+			a.Flags |= StyleReverse
+		case n >= ANSIFg && n <= ANSIFg+7:
+			a.Fg = ColorBlack + Color(n-ANSIFg)
+		case n == ANSIFg+ANSIDefault:
+			a.Fg = ColorDefault
+		case n >= ANSIBg && n <= ANSIBg+7:
+			a.Bg = ColorBlack + Color(n-ANSIBg)
+		case n == ANSIBg+ANSIDefault:
+			a.Bg = ColorDefault
 		default:
 			return nil, 0, ErrNotEscSequence
 		}
@@ -204,34 +224,42 @@ func Unescape(s string) string {
 	return ret
 }
 
-// Cell contains a single screen cell.
-// This structure exists in order to mark cells that require rendering.
-// This is required in order to only render deltas, this matters over slow
-// links.
+// Cell contains a single screen cell.  A zero Ch is displayed as a space.
 type Cell struct {
-	termbox.Cell      // anon since we are only adding the dirty bit
-	dirty        bool // like your mom
+	Ch    rune // character
+	Style      // how to draw Ch
 }
 
-// Attributes represents attributes which are defined as text color, bold,
-// blink etc.
-type Attributes struct {
-	Fg termbox.Attribute // foreground
-	Bg termbox.Attribute // background
+// FlushStats counts what flush sent to the terminal.  Only cells that differ
+// from what is already on the terminal are sent, this matters over slow
+// links.
+type FlushStats struct {
+	Flushes int // number of flushes
+	Cells   int // cells sent to the terminal
+	Bytes   int // bytes written, 0 if the backend can not tell
 }
 
 var (
-	// ErrAlreadyInitialized is used on reentrant calls of Init.
+	// ErrAlreadyInitialized is used on reentrant calls of Init and
+	// InitTerminal.
 	ErrAlreadyInitialized = errors.New("terminal already initialized")
 
-	// terminal
-	maxX       int        // max x
-	maxY       int        // max y
-	termRaw    bool       // true in raw managed window mode
-	keyHandler bool       // true if key handler has been launched
-	rawMtx     sync.Mutex // required for switching terminal modes
+	// ErrNotTTY is generated when a terminal device is required.
+	ErrNotTTY = errors.New("not a terminal device")
 
-	// all render and termbox access must go through this channel
+	// terminal
+	maxX    int        // max x
+	maxY    int        // max y
+	termRaw bool       // true in raw managed window mode
+	rawMtx  sync.Mutex // required for switching terminal modes
+	term    backend    // physical terminal
+	front   []Cell     // what is currently on the terminal
+
+	// flush statistics
+	lastStats  FlushStats // last flush
+	totalStats FlushStats // all flushes since Init
+
+	// all render and terminal access must go through this channel
 	work chan func() // render work queue
 
 	// windows
@@ -245,8 +273,8 @@ var (
 	windower2window map[Windower]*Window
 
 	// defaults
-	bg termbox.Attribute // background color
-	fg termbox.Attribute // foreground color
+	bg Color // background color
+	fg Color // foreground color
 )
 
 // init sets up all global variables and prepares ttk for use.
@@ -304,48 +332,22 @@ func init() {
 	wg.Wait()
 }
 
-// initKeyHandler starts the internal key handler.
-// Must be called with mutex held and as a go routine.
-func initKeyHandler() {
+// initKeyHandler starts the internal key handler.  It returns when the
+// backend is closed.  Must be called as a go routine.
+func initKeyHandler(b backend) {
 	for {
-		switch ev := termbox.PollEvent(); ev.Type {
-		case termbox.EventKey:
-			e := ev
+		switch ev := b.pollEvent(); ev.typ {
+		case eventKey:
+			k := ev.key
 			Queue(func() {
-				var (
-					widget Widgeter
-					window Windower
-				)
-				if focus != nil {
-					var used bool
-					used, window, widget = focus.keyHandler(e)
-					if used {
-						flush()
-						return
-					}
-				}
-
-				// forward to global application handler
-				keyC <- Key{
-					Mod:    e.Mod,
-					Key:    e.Key,
-					Ch:     e.Ch,
-					Window: window,
-					Widget: widget,
-				}
-				// XXX this is a terrible workaround!!
-				// the app is racing this channel
-				// we need to somehow block here before doing
-				// anything else
-				//time.Sleep(25 * time.Millisecond)
+				handleKey(k)
 			})
 
-		case termbox.EventResize:
+		case eventResize:
 			Queue(func() {
 				resizeAndRender(focus)
 			})
-		case termbox.EventMouse:
-		case termbox.EventError:
+		case eventError:
 			return
 		}
 	}
@@ -354,6 +356,21 @@ func initKeyHandler() {
 // Init switches the terminal to raw mode and commences managed window mode.
 // This function shall be called prior to any ttk calls.
 func Init() error {
+	return initBackend(&termboxBackend{})
+}
+
+// InitTerminal is like Init but drives the provided Terminal instead of the
+// controlling terminal of the process.  Windows, overlays and keymaps are
+// global so a process drives one terminal at a time; InitTerminal returns
+// ErrAlreadyInitialized until Deinit was called.  Serve several sessions from
+// separate processes.
+func InitTerminal(t *Terminal) error {
+	return initBackend(t)
+}
+
+// initBackend switches the backend to raw mode, launches the key handler and
+// commences managed window mode.
+func initBackend(b backend) error {
 	rawMtx.Lock()
 	defer rawMtx.Unlock()
 
@@ -362,24 +379,26 @@ func Init() error {
 	}
 
 	// switch mode
-	err := termbox.Init()
+	err := b.init()
 	if err != nil {
 		return err
 	}
 
-	bg = termbox.ColorDefault
-	fg = termbox.ColorDefault
-	termbox.HideCursor()
-	termbox.SetInputMode(termbox.InputAlt) // this may need to become variable
-	termbox.Clear(bg, bg)
-	maxX, maxY = termbox.Size()
-	_ = termbox.Flush()
-
-	// see if we need to launch the key handler
-	if !keyHandler {
-		go initKeyHandler()
-		keyHandler = true
+	bg = ColorDefault
+	fg = ColorDefault
+	b.clear(defaultStyle())
+	maxX, maxY = b.size()
+	resetFront()
+	lastStats = FlushStats{}
+	totalStats = FlushStats{}
+	_, err = b.flush()
+	if err != nil {
+		b.close()
+		return err
 	}
+	term = b
+
+	go initKeyHandler(b)
 
 	termRaw = true // we are now in raw mode
 
@@ -394,14 +413,20 @@ func Init() error {
 func Deinit() {
 	wait := make(chan interface{})
 	Queue(func() {
-		termbox.Close()
-		focus = nil
-		prevFocus = nil
-		windows = make(map[int]*Window) // toss all windows
-
 		rawMtx.Lock()
+		if term != nil && termRaw {
+			term.close()
+		}
+		term = nil
 		termRaw = false
 		rawMtx.Unlock()
+		linearOut = nil
+
+		focus = nil
+		prevFocus = nil
+		windows = make(map[int]*Window) // toss all windows
+		overlays = nil
+		tiling = nil
 
 		wait <- true
 	})
@@ -424,24 +449,34 @@ func KeyChannel() chan Key {
 func NewWindow(manager Windower) *Window {
 	wc := make(chan *Window)
 	Queue(func() {
-		w := &Window{
-			id:           lastWindowID,
-			mgr:          manager,
-			x:            maxX,
-			y:            maxY,
-			focus:        -1, // no widget focused
-			backingStore: make([]Cell, maxX*maxY),
-			widgets:      make([]Widgeter, 0, 16),
-		}
-		lastWindowID++
+		w := newWindow(manager, maxX, maxY)
 		windows[w.id] = w
-		windower2window[manager] = w
 		manager.Init(w)
 		wc <- w
 	})
 	return <-wc
 }
 
+// newWindow allocates a window of the provided size.
+// newWindow shall be called from queue context.
+func newWindow(manager Windower, x, y int) *Window {
+	w := &Window{
+		id:           lastWindowID,
+		mgr:          manager,
+		x:            x,
+		y:            y,
+		cursorX:      -1,
+		cursorY:      -1,
+		focus:        -1, // no widget focused
+		backingStore: make([]Cell, x*y),
+		widgets:      make([]Widgeter, 0, 16),
+		keymap:       NewKeymap(),
+	}
+	lastWindowID++
+	windower2window[manager] = w
+	return w
+}
+
 // ForwardKey must be called from the application to route key strokes to
 // windows.  The life cycle of keystrokes is as follows: widgets -> global
 // application context -> window.  Care must be taken in the application to not
@@ -453,46 +488,91 @@ func ForwardKey(k Key) {
 	k.Window.KeyHandler(windower2window[k.Window], k)
 }
 
-// defaultAttributes returns the default attributes.
-// defaultAttributes shall be called from queue context.
-func defaultAttributes() Attributes {
-	return Attributes{
+// defaultStyle returns the default style.
+// defaultStyle shall be called from queue context.
+func defaultStyle() Style {
+	return Style{
 		Fg: fg,
 		Bg: bg,
 	}
 }
 
-// DefaultAttributes returns the default attributes.
+// DefaultStyle returns the default style.
 // This is a blocking call.
-func DefaultAttributes() Attributes {
-	c := make(chan Attributes)
+func DefaultStyle() Style {
+	c := make(chan Style)
 	Queue(func() {
-		c <- defaultAttributes()
+		c <- defaultStyle()
 	})
 	return <-c
 }
 
-// flush copies focused window backing store onto the physical screen.
+// resetFront marks the entire terminal as blank.  resetFront shall be called
+// after the terminal was cleared.
+func resetFront() {
+	front = make([]Cell, maxX*maxY)
+	for i := range front {
+		front[i].Ch = ' '
+		front[i].Bg = bg
+	}
+}
+
+// flush composites the overlays over the focused window backing store and
+// copies the cells that differ from what is on the physical screen.
 // flush shall be called from queue context.
 func flush() {
-	if focus == nil {
+	if focus == nil || term == nil || linearOut != nil {
 		return
 	}
-	for y := 0; y < focus.y; y++ {
-		for x := 0; x < focus.x; x++ {
-			c := focus.getCell(x, y)
-			if !c.dirty {
+
+	var stats FlushStats
+	stats.Flushes = 1
+	for y := 0; y < maxY; y++ {
+		for x := 0; x < maxX; x++ {
+			c := screenCell(x, y)
+
+			f := &front[x+y*maxX]
+			if *f == c {
 				// skip unchanged cells
 				continue
 			}
-			c.dirty = false
+			*f = c
+			stats.Cells++
 
 			// this shall be the only spot where
-			// termbox.SetCell is called!
-			termbox.SetCell(x, y, c.Ch, c.Fg, c.Bg)
+			// term.setCell is called!
+			term.setCell(x, y, c)
 		}
 	}
-	_ = termbox.Flush()
+	// cursor belongs to the window that receives keyboard input
+	cx, cy := -1, -1
+	shape := CursorDefault
+	if iw := inputWindow(); iw != nil && iw.cursorX >= 0 &&
+		iw.cursorY >= 0 && iw.cursorX < iw.x && iw.cursorY < iw.y {
+		cx = iw.originX + iw.cursorX
+		cy = iw.originY + iw.cursorY
+		shape = iw.cursorShape
+	}
+	term.setCursor(cx, cy)
+	term.setCursorShape(shape)
+	stats.Bytes, _ = term.flush()
+
+	lastStats = stats
+	totalStats.Flushes += stats.Flushes
+	totalStats.Cells += stats.Cells
+	totalStats.Bytes += stats.Bytes
+}
+
+// Stats returns the statistics of the last flush and the running totals since
+// Init.
+// This is a blocking call.
+func Stats() (FlushStats, FlushStats) {
+	c := make(chan [2]FlushStats)
+	Queue(func() {
+		c <- [2]FlushStats{lastStats, totalStats}
+	})
+	s := <-c
+	return s[0], s[1]
 }
 
 // Flush copies focused window backing store onto the physical screen.
@@ -502,12 +582,6 @@ func Flush() {
 	})
 }
 
-// setCursor sets the cursor at the specified location.  This will not show
-// immediately.  setCursor shall be called from queue context.
-func setCursor(x, y int) {
-	termbox.SetCursor(x, y)
-}
-
 // focus on provided window. This will implicitly focus on a window widget
 // that can have focus.  Render and flush it onto the terminal.
 // focus shall be called from queue context.
@@ -522,21 +596,59 @@ func focusWindow(w *Window) {
 	if focus == w {
 		return
 	}
+
+	// a window that is not tiled replaces the focused pane
+	if tiling != nil {
+		if p := tiling.find(w); p == nil {
+			if p = tiling.find(focus); p != nil {
+				p.window = w
+			}
+		}
+	}
+
+	if focus != nil {
+		focus.blur()
+	}
 	prevFocus = focus
 	focus = w
+	focus.clearActivity()
 
+	if w.title != "" {
+		announce("window: %v", w.title)
+	}
+	prev := w.focusedWidget()
 	resizeAndRender(w)
+	if widget := w.focusedWidget(); widget == prev {
+		// focusIndex did not announce it
+		announceWidget(widget)
+	}
 }
 
-// resizeAndRender resizes a window and renders it.
+// resizeAndRender resizes a window and renders it.  When tiling all panes are
+// resized and rendered instead.  The terminal is only cleared when its size
+// changed; otherwise flush sends the differences.
 func resizeAndRender(w *Window) {
 	// render window
-	if w != nil {
-		termbox.Clear(bg, bg)
-		maxX, maxY = termbox.Size()
+	if w != nil && term != nil {
+		x, y := term.size()
+		if x != maxX || y != maxY {
+			maxX, maxY = x, y
+			term.clear(defaultStyle())
+			resetFront()
+		}
 
-		w.resize(maxX, maxY)
-		w.render()
+		if tiling != nil {
+			tiling.layout(0, 0, maxX, maxY)
+		} else {
+			w.originX = 0
+			w.originY = 0
+			w.resize(maxX, maxY)
+			w.render()
+		}
+		for _, o := range overlays {
+			o.place()
+			o.render()
+		}
 
 		// display all the things
 		flush()
@@ -560,16 +672,99 @@ func FocusPrevious() {
 	})
 }
 
+// CloseWindow destroys the window.  If the window was focused the previously
+// focused window is focused instead, or any remaining window if there is
+// none.  A closed window can no longer be focused.  Overlays are closed as if
+// CloseOverlay was called.
+func CloseWindow(w *Window) {
+	Queue(func() {
+		closeWindow(w)
+	})
+}
+
+// closeWindow removes the window from all bookkeeping and picks a new focus if
+// needed.
+// closeWindow shall be called from queue context.
+func closeWindow(w *Window) {
+	if w == nil {
+		return
+	}
+	if w.overlay != nil {
+		closeOverlay(w)
+		flush()
+		return
+	}
+	if _, found := windows[w.id]; !found {
+		return
+	}
+	delete(windows, w.id)
+	if windower2window[w.mgr] == w {
+		delete(windower2window, w.mgr)
+	}
+	if tiling != nil {
+		if p := tiling.find(w); p != nil {
+			tiling = p.remove(tiling)
+		}
+	}
+	if prevFocus == w {
+		prevFocus = nil
+	}
+	w.blur()
+
+	// release resources
+	w.backingStore = nil
+	w.widgets = nil
+	w.layout = nil
+
+	if focus != w {
+		resizeAndRender(focus)
+		return
+	}
+
+	// fall back to a tiled window, the previous window or any window
+	next := prevFocus
+	if tiling != nil && (next == nil || tiling.find(next) == nil) {
+		if l := tiling.leaves(); len(l) > 0 {
+			next = l[0].window
+		}
+	}
+	if next == nil {
+		for _, v := range windows {
+			if next == nil || v.id < next.id {
+				next = v
+			}
+		}
+	}
+	focus = nil
+	prevFocus = nil
+	if next == nil {
+		// nothing left to display
+		flush()
+		return
+	}
+	focusWindow(next)
+}
+
+// closeTerm restores the terminal without going through the queue.
+func closeTerm() {
+	rawMtx.Lock()
+	defer rawMtx.Unlock()
+	if term != nil && termRaw {
+		term.close()
+		termRaw = false
+	}
+}
+
 // Panic application but deinit first so that the terminal will not be corrupt.
 func Panic(format string, args ...interface{}) {
-	termbox.Close()
+	closeTerm()
 	msg := fmt.Sprintf(format, args...)
 	panic(msg)
 }
 
 // Exit application but deinit first so that the terminal will not be corrupt.
 func Exit(format string, args ...interface{}) {
-	termbox.Close()
+	closeTerm()
 	fmt.Fprintf(os.Stderr, format+"\n", args...)
 	os.Exit(1)
 }
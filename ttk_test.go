@@ -5,15 +5,25 @@
 package ttk
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
+// newTestWindow returns a window of the provided size that is not displayed.
+// The returned function forgets the window again.
+func newTestWindow(x, y int) (*Window, func()) {
+	w := newWindow(&funcWindow{}, x, y)
+	return w, func() { delete(windower2window, w.mgr) }
+}
+
 func TestUnescape(t *testing.T) {
-	redbold, _ := Color(AttrBold, ColorRed, AttrNA)
-	blue, _ := Color(AttrNA, ColorBlue, AttrNA)
-	greencyan, _ := Color(AttrNA, ColorGreen, ColorCyan)
-	reset, _ := Color(AttrReset, AttrNA, AttrNA)
+	redbold, _ := Escape(AttrBold, ColorRed, AttrNA)
+	blue, _ := Escape(AttrNA, ColorBlue, AttrNA)
+	greencyan, _ := Escape(AttrNA, ColorGreen, ColorCyan)
+	reset, _ := Escape(AttrReset, AttrNA, AttrNA)
 
 	redTest := fmt.Sprintf("lalala %vmoo%v test", redbold, reset)
 	redU := Unescape(redTest)
@@ -33,3 +43,1066 @@ func TestUnescape(t *testing.T) {
 		t.Fatalf("greencyan")
 	}
 }
+
+func TestColorEscape(t *testing.T) {
+	old, err := ColorEscape(AttrBold, 1, AttrNA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redbold, _ := Escape(AttrBold, ColorRed, AttrNA); old != redbold {
+		t.Fatalf("got %q want %q", old, redbold)
+	}
+	if _, err = ColorEscape(AttrNA, AttrNA, 8); err != ErrInvalidBackground {
+		t.Fatalf("invalid background: got %v", err)
+	}
+}
+
+func TestDecodeColor(t *testing.T) {
+	es, err := Escape(AttrBold, ColorRed, ColorDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, skip, err := DecodeColor(es + "moo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skip != len(es) {
+		t.Fatalf("skip: got %v want %v", skip, len(es))
+	}
+	want := Style{Fg: ColorRed, Bg: ColorDefault, Flags: StyleBold}
+	if *s != want {
+		t.Fatalf("style: got %+v want %+v", *s, want)
+	}
+}
+
+func TestStyleTermbox(t *testing.T) {
+	for _, s := range []Style{
+		{},
+		{Fg: ColorYellow, Bg: ColorBlue},
+		{Fg: ColorWhite, Bg: ColorBlack, Flags: StyleBold | StyleReverse},
+		{Bg: ColorCyan, Flags: StyleUnderline},
+	} {
+		fg, bg := s.Termbox()
+		if got := StyleFromTermbox(fg, bg); got != s {
+			t.Fatalf("got %+v want %+v", got, s)
+		}
+	}
+}
+
+func TestPaneSizes(t *testing.T) {
+	p := NewSplit(SplitColumns, PaneSize{},
+		NewPane(nil, PaneSize{Fixed: 10}),
+		NewPane(nil, PaneSize{Ratio: 2}),
+		NewPane(nil, PaneSize{}))
+	sizes := p.sizes(41)
+	want := []int{10, 20, 11}
+	for i := range want {
+		if sizes[i] != want[i] {
+			t.Fatalf("got %v want %v", sizes, want)
+		}
+	}
+
+	// dividers take a column each
+	p.layout(0, 0, 43, 10)
+	if w, r := p.cellAt(10, 5); w != nil || r != '│' {
+		t.Fatalf("expected divider at 10")
+	}
+	if c := p.children[2]; c.x != 32 || c.width != 11 || c.height != 10 {
+		t.Fatalf("unexpected area %v,%v %vx%v", c.x, c.y, c.width,
+			c.height)
+	}
+}
+
+func TestPaneActions(t *testing.T) {
+	a, doneA := newTestWindow(20, 5)
+	defer doneA()
+	b, doneB := newTestWindow(20, 5)
+	defer doneB()
+	a.title = "a"
+	b.title = "b"
+	b.AddEditText(0, 0, 10, "hi").SetName("nick")
+
+	pa := NewPane(a, PaneSize{})
+	pb := NewPane(b, PaneSize{})
+	tiling = NewSplit(SplitColumns, PaneSize{}, pa, pb)
+	tiling.layout(0, 0, 41, 5)
+	focus = a
+	var out bytes.Buffer
+	linearOut = &out
+	defer func() {
+		tiling = nil
+		focus = nil
+		linearOut = nil
+	}()
+
+	// focus moves to the widget of the pane and is announced
+	windowActions[ActionPaneNext](a)
+	if focus != b || b.focusedWidget() == nil {
+		t.Fatalf("pane not focused")
+	}
+	want := "window: b\r\nedit: nick, contents hi\r\n"
+	if got := out.String(); got != want {
+		t.Fatalf("announce: got %q", got)
+	}
+
+	windowActions[ActionPaneGrow](b)
+	if pa.Size.Ratio != 19 || pb.Size.Ratio != 21 {
+		t.Fatalf("grow: got %v %v", pa.Size.Ratio, pb.Size.Ratio)
+	}
+	tiling.layout(0, 0, 41, 5)
+	windowActions[ActionPanePrevious](b)
+	windowActions[ActionPaneGrow](a)
+	windowActions[ActionPaneShrink](a)
+	windowActions[ActionPaneShrink](a)
+	if focus != a || pa.Size.Ratio != 18 || pb.Size.Ratio != 22 {
+		t.Fatalf("shrink: got %v %v", pa.Size.Ratio, pb.Size.Ratio)
+	}
+}
+
+func TestLayoutSizes(t *testing.T) {
+	l := NewLayout(SplitRows, Constraint{},
+		NewLayoutWidget(nil, Constraint{Fixed: 1}),
+		NewLayoutWidget(nil, Constraint{Percent: 50}),
+		NewLayoutWidget(nil, Constraint{Max: 5}),
+		NewLayoutWidget(nil, Constraint{}),
+		NewLayoutWidget(nil, Constraint{Fixed: 1}))
+	tests := []struct {
+		avail int
+		want  []int
+	}{
+		{40, []int{1, 20, 5, 13, 1}},
+		{10, []int{1, 5, 1, 2, 1}},
+		{4, []int{1, 2, 0, 0, 1}},
+		{1, []int{1, 0, 0, 0, 0}},
+	}
+	for _, test := range tests {
+		sizes := l.sizes(test.avail)
+		for i := range test.want {
+			if sizes[i] != test.want[i] {
+				t.Fatalf("%v: got %v want %v", test.avail, sizes,
+					test.want)
+			}
+		}
+	}
+
+	// min is honored even if the fill share is smaller
+	l = NewLayout(SplitColumns, Constraint{},
+		NewLayoutWidget(nil, Constraint{Min: 8}),
+		NewLayoutWidget(nil, Constraint{}))
+	sizes := l.sizes(10)
+	if sizes[0] != 8 || sizes[1] != 2 {
+		t.Fatalf("got %v want [8 2]", sizes)
+	}
+}
+
+func TestAnchor(t *testing.T) {
+	tests := []struct {
+		pos, size, total int
+		at, want         int
+		min              int
+	}{
+		{2, 5, 20, 2, 5, 7},
+		{2, 0, 20, 2, 18, 3},
+		{2, -2, 20, 2, 16, 5},
+		{-2, 1, 20, 18, 1, 2},
+		{-2, 0, 20, 18, 2, 2},
+		{-3, -1, 20, 17, 2, 3},
+		{-1, 3, 20, 19, 1, 1},
+		{-1, -1, 20, 19, 0, 1},
+		{2, 5, 4, 2, 2, 7},
+		{-5, 1, 3, 0, 1, 5},
+	}
+	for _, test := range tests {
+		at, size := anchorAxis(test.pos, test.size, test.total)
+		if at != test.at || size != test.want {
+			t.Errorf("%v,%v in %v: got %v,%v want %v,%v", test.pos,
+				test.size, test.total, at, size, test.at, test.want)
+		}
+		l, _ := anchorLayout(test.pos, test.size, 1)
+		if min, _ := l.minSize(); min != test.min {
+			t.Errorf("%v,%v: min got %v want %v", test.pos, test.size,
+				min, test.min)
+		}
+	}
+}
+
+func TestFramePlacement(t *testing.T) {
+	w, done := newTestWindow(20, 5)
+	defer done()
+
+	// the anchor of a widget inside a frame does not matter
+	e := w.AddEditText(30, 0, 10, "")
+	f := w.AddFrame(0, 0, 0, 0, BorderSingle)
+	f.SetLayout(NewLayout(SplitColumns, Constraint{},
+		NewLayoutWidget(e, Constraint{Min: 10})))
+	w.resize(20, 5)
+	if w.tooSmall || e.trueX != 1 || e.trueY != 1 || e.trueW != 18 {
+		t.Fatalf("placed at %v,%v width %v", e.trueX, e.trueY, e.trueW)
+	}
+
+	// the frame requires its border and the minimum of its layout
+	w.resize(11, 5)
+	if !w.tooSmall || w.needX != 12 || w.needY != 2 {
+		t.Fatalf("need %vx%v", w.needX, w.needY)
+	}
+}
+
+func TestRemoveWidget(t *testing.T) {
+	var a, b string
+	w, done := newTestWindow(20, 5)
+	defer done()
+	ea := w.AddEdit(0, 0, 10, &a)
+	l := w.AddLabel(0, 1, "label")
+	eb := w.AddEdit(0, 2, 10, &b)
+	w.render()
+	w.focusNext()
+	if w.focus != 2 {
+		t.Fatalf("focus: got %v want 2", w.focus)
+	}
+
+	w.RemoveWidget(l)
+	if w.focus != 1 || len(w.widgets) != 2 {
+		t.Fatalf("focus: got %v want 1", w.focus)
+	}
+	if c := w.getCell(0, 1); c.Ch != ' ' {
+		t.Fatalf("label area not cleared: %q", c.Ch)
+	}
+
+	// removing the focused widget wraps around to the first one
+	w.RemoveWidget(eb)
+	if w.focus != 0 || w.widgets[0] != ea {
+		t.Fatalf("focus: got %v want 0", w.focus)
+	}
+
+	// removing the last widget of the tab order restores the default order
+	l = w.AddLabel(0, 1, "label")
+	eb = w.AddEdit(0, 2, 10, &b)
+	w.SetTabOrder(ea)
+	w.RemoveWidget(ea)
+	if w.tabOrder != nil {
+		t.Fatalf("tab order: %v", w.tabOrder)
+	}
+	w.focusNext()
+	if w.focusedWidget() != eb {
+		t.Fatalf("focus: got %v", w.focus)
+	}
+}
+
+func TestCloseWindow(t *testing.T) {
+	ws := make([]*Window, 4)
+	for i := range ws {
+		w, done := newTestWindow(20, 5)
+		defer done()
+		windows[w.id] = w
+		ws[i] = w
+	}
+	defer func() {
+		for _, w := range ws {
+			delete(windows, w.id)
+		}
+		focus = nil
+		prevFocus = nil
+		tiling = nil
+	}()
+	a, b, c, d := ws[0], ws[1], ws[2], ws[3]
+
+	// without a previous window the lowest id is next
+	focusWindow(c)
+	closeWindow(c)
+	if focus != a {
+		t.Fatalf("lowest id: got %v", focus.id)
+	}
+
+	// the previous window is next
+	focusWindow(d)
+	focusWindow(b)
+	closeWindow(b)
+	if focus != d {
+		t.Fatalf("previous: got %v", focus.id)
+	}
+
+	// a tiled window is next if the previous one is not tiled
+	e, done := newTestWindow(20, 5)
+	defer done()
+	windows[e.id] = e
+	ws = append(ws, e)
+	tiling = NewSplit(SplitColumns, PaneSize{}, NewPane(a, PaneSize{}),
+		NewPane(e, PaneSize{}))
+	focus = e
+	prevFocus = d
+	closeWindow(e)
+	if focus != a || tiling.find(e) != nil {
+		t.Fatalf("tiled: got %v", focus.id)
+	}
+
+	// closed windows can not be focused
+	for _, w := range []*Window{b, c, e} {
+		focusWindow(w)
+		if focus != a {
+			t.Fatalf("closed window %v focused", w.id)
+		}
+	}
+}
+
+func TestActivity(t *testing.T) {
+	// managers must be distinct
+	w1 := NewWindow(&funcWindow{})
+	w2 := NewWindow(&funcWindow{})
+	w3 := NewWindow(&funcWindow{})
+	defer func() {
+		CloseWindow(w1)
+		CloseWindow(w2)
+		CloseWindow(w3)
+	}()
+	if LookupWindow(w2.mgr) != w2 {
+		t.Fatalf("lookup failed")
+	}
+	ws := Windows()
+	if len(ws) < 3 || ws[len(ws)-3] != w1 || ws[len(ws)-1] != w3 {
+		t.Fatalf("windows not in creation order")
+	}
+
+	focused := func() *Window {
+		wc := make(chan *Window)
+		Queue(func() {
+			wc <- focus
+		})
+		return <-wc
+	}
+	Focus(w1)
+	Queue(func() {
+		w1.SetActivity(ActivityHigh) // focused, ignored
+		w2.SetActivity(ActivityLow)
+		w3.SetActivity(ActivityNormal)
+	})
+	FocusNextActive()
+	if focused() != w3 {
+		t.Fatalf("expected most active window")
+	}
+	FocusNextActive()
+	if focused() != w2 {
+		t.Fatalf("expected remaining active window")
+	}
+	FocusNextActive()
+	if focused() != w2 {
+		t.Fatalf("focus changed without activity")
+	}
+}
+
+func TestActivityTiled(t *testing.T) {
+	a := NewWindow(&funcWindow{})
+	b := NewWindow(&funcWindow{})
+	c := NewWindow(&funcWindow{})
+	defer func() {
+		CloseWindow(a)
+		CloseWindow(b)
+		CloseWindow(c)
+		Queue(func() {
+			tiling = nil
+		})
+	}()
+
+	activity := make(chan [2]Activity)
+	Queue(func() {
+		tiling = NewSplit(SplitColumns, PaneSize{},
+			NewPane(a, PaneSize{}), NewPane(b, PaneSize{}))
+		focusWindow(a)
+
+		// b is displayed next to a, c is not
+		b.SetActivity(ActivityHigh)
+		c.SetActivity(ActivityHigh)
+		activity <- [2]Activity{b.Activity(), c.Activity()}
+	})
+	if got := <-activity; got != [2]Activity{ActivityNone, ActivityHigh} {
+		t.Fatalf("activity: got %v", got)
+	}
+}
+
+func TestKeymap(t *testing.T) {
+	err := LoadKeymap(strings.NewReader(`
+# test bindings
+global test.quit Ctrl-X Ctrl-C
+global test.up   Alt-Up
+edit   test.home Ctrl-B
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		GlobalKeymap().Unbind("Ctrl-X Ctrl-C")
+		GlobalKeymap().Unbind("Alt-Up")
+		WidgetKeymap(WidgetEdit).Unbind("Ctrl-B")
+	}()
+	if err := LoadKeymap(strings.NewReader("nope x Ctrl-A")); err == nil {
+		t.Fatalf("expected invalid scope")
+	}
+	if a, _ := WidgetKeymap(WidgetEdit).lookup([]Key{{Key: KeyCtrlB}}); a != "test.home" {
+		t.Fatalf("edit: got %q", a)
+	}
+
+	SetChordTimeout(20 * time.Millisecond)
+	defer SetChordTimeout(time.Second)
+	send := func(keys ...Key) {
+		for _, k := range keys {
+			k := k
+			Queue(func() {
+				handleKey(k)
+			})
+		}
+	}
+	expect := func(want Key) {
+		select {
+		case k := <-KeyChannel():
+			if k != want {
+				t.Fatalf("got %+v want %+v", k, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %+v", want)
+		}
+	}
+
+	// completed chord
+	send(Key{Key: KeyCtrlX}, Key{Key: KeyCtrlC})
+	expect(Key{Key: KeyCtrlC, Action: "test.quit"})
+	send(Key{Mod: ModAlt, Key: KeyArrowUp})
+	expect(Key{Mod: ModAlt, Key: KeyArrowUp, Action: "test.up"})
+
+	// broken chord delivers the keys as they are
+	send(Key{Key: KeyCtrlX}, Key{Ch: 'a'})
+	expect(Key{Key: KeyCtrlX})
+	expect(Key{Ch: 'a'})
+
+	// expired chord
+	send(Key{Key: KeyCtrlX})
+	expect(Key{Key: KeyCtrlX})
+}
+
+// blurEdit counts how often it lost focus.
+type blurEdit struct {
+	*Edit
+	blurred int
+}
+
+func (b *blurEdit) Blur() {
+	b.blurred++
+}
+
+func TestFocus(t *testing.T) {
+	var s string
+	w, done := newTestWindow(20, 5)
+	defer done()
+	e0 := &blurEdit{Edit: w.AddEdit(0, 0, 10, &s)}
+	w.widgets[0] = e0
+	w.AddLabel(0, 1, "label")
+	e2 := w.AddEdit(0, 2, 10, &s)
+	e3 := w.AddEdit(0, 3, 10, &s)
+	w.render()
+
+	focused := func(want Widgeter) {
+		if w.focusedWidget() != want {
+			t.Fatalf("focused %v", w.focus)
+		}
+	}
+	focused(e0)
+
+	// previous wraps around and reaches the first widget
+	w.focusPrevious()
+	focused(e3)
+	if e0.blurred != 1 {
+		t.Fatalf("blurred %v", e0.blurred)
+	}
+	w.focusPrevious()
+	focused(e2)
+	w.focusPrevious()
+	focused(e0)
+
+	// disabled and hidden widgets are skipped
+	e2.SetEnabled(false)
+	w.focusNext()
+	focused(e3)
+	e3.Visibility(VisibilityHide)
+	w.render()
+	focused(e0)
+	w.focusNext()
+	focused(e0)
+	e2.SetEnabled(true)
+	e3.Visibility(VisibilityShow)
+
+	// custom order
+	w.SetTabOrder(e3, e0)
+	w.focusNext()
+	focused(e3)
+	w.focusNext()
+	focused(e0)
+	w.SetTabOrder()
+	w.focusNext()
+	focused(e2)
+}
+
+func TestEditCallbacks(t *testing.T) {
+	target := "ab"
+	w, done := newTestWindow(20, 5)
+	defer done()
+	e := w.AddEdit(0, 0, 10, &target)
+	w.render()
+
+	var (
+		changed   string
+		submitted string
+		canceled  bool
+	)
+	e.OnChange(func(s string) { changed = s })
+	e.OnSubmit(func(s string) bool {
+		submitted = s
+		return true
+	})
+	e.OnCancel(func() { canceled = true })
+
+	e.KeyHandler(Key{Ch: 'c'})
+	if changed != "abc" || target != "abc" {
+		t.Fatalf("change: got %q/%q", changed, target)
+	}
+	e.KeyHandler(Key{Key: KeyBackspace2, Action: ActionEditBackspace})
+	if changed != "ab" || target != "ab" {
+		t.Fatalf("backspace: got %q/%q", changed, target)
+	}
+	if !e.KeyHandler(Key{Key: KeyEnter, Action: ActionEditSubmit}) ||
+		submitted != "ab" {
+		t.Fatalf("submit: got %q", submitted)
+	}
+	if !e.KeyHandler(Key{Key: KeyEsc, Action: ActionEditCancel}) ||
+		!canceled {
+		t.Fatalf("cancel not called")
+	}
+
+	// setting the value is not a change
+	changed = ""
+	e.SetValue("xyz", true)
+	if changed != "" || target != "xyz" || e.GetText() != "xyz" {
+		t.Fatalf("set: got %q/%q", changed, target)
+	}
+}
+
+func TestBidi(t *testing.T) {
+	tests := []struct {
+		logical string
+		visual  string
+	}{
+		{"hello world", "hello world"},
+		{"שלום", "םולש"},
+		{"hello שלום עולם!", "hello םלוע םולש!"},
+		{"שלום 123", "123 םולש"},
+		{"שלום hello world", "hello world םולש"},
+		{"(שלום)", "(םולש)"},
+		{"שלום  ", "  םולש"},
+
+		// explicit embeddings, overrides and isolates are neutrals
+		{"ab \u202bשלום\u202c", "ab \u202bםולש\u202c"},
+		{"\u202eabc\u202c", "\u202eabc\u202c"},
+		{"\u2067abc\u2069 שלום", "\u2067abc\u2069 םולש"},
+	}
+	for _, test := range tests {
+		var cells []Cell
+		for _, r := range test.logical {
+			cells = append(cells, Cell{Ch: r})
+		}
+		visual := ""
+		for _, c := range bidiCells(cells) {
+			visual += string(c.Ch)
+		}
+		if visual != test.visual {
+			t.Errorf("%q: got %q want %q", test.logical, visual,
+				test.visual)
+		}
+	}
+
+	// the edit cursor moves in logical order
+	w, done := newTestWindow(10, 1)
+	defer done()
+	e := w.AddEditText(0, 0, 10, "ab שלום")
+	w.render()
+	if line := lines(w.Canvas())[0]; line != "ab םולש   " {
+		t.Fatalf("render: got %q", line)
+	}
+	e.KeyHandler(Key{Action: ActionEditHome})
+	for _, want := range []int{0, 1, 2, 6, 5, 4, 3, 3} {
+		if w.cursorX != want {
+			t.Fatalf("cursor: got %v want %v", w.cursorX, want)
+		}
+		e.KeyHandler(Key{Action: ActionEditRight})
+	}
+}
+
+func TestTooSmall(t *testing.T) {
+	w, done := newTestWindow(20, 5)
+	defer done()
+	l := w.AddList(0, 2, 0, -1)
+	l.Append("hello")
+	e := w.AddEditText(10, 0, 5, "abc")
+	line := func(y int) string { return lines(w.Canvas())[y] }
+
+	w.resize(14, 5)
+	w.render()
+	if !w.tooSmall || line(2) != "terminal too s" {
+		t.Fatalf("too small: %v %q", w.tooSmall, line(2))
+	}
+	if used, _, _ := w.keyHandler(Key{Ch: 'x'}); used {
+		t.Fatalf("key used while too small")
+	}
+
+	// the window minimum counts as well
+	w.SetMinSize(40, 3)
+	w.resize(30, 5)
+	w.render()
+	if line(2) != "terminal too small (need 40x4)" {
+		t.Fatalf("min size: %q", line(2))
+	}
+
+	// tiny terminals do not panic
+	w.resize(1, 1)
+	w.render()
+	l.Render()
+	e.Render()
+
+	w.SetMinSize(0, 0)
+	w.resize(20, 5)
+	w.render()
+	if w.tooSmall || line(0) != "          abc       " ||
+		line(2) != "hello               " {
+		t.Fatalf("resume: %q %q", line(0), line(2))
+	}
+}
+
+func TestTextArea(t *testing.T) {
+	w, done := newTestWindow(10, 2)
+	defer done()
+	ta := w.AddTextArea(0, 0, 0, 0, "hello world foo bar")
+	ta.SetValue("hello world foo bar", true)
+	line := func(y int) string { return lines(w.Canvas())[y] }
+	cursor := func(x, y int) {
+		if w.cursorX != x || w.cursorY != y {
+			t.Fatalf("cursor: got %v,%v want %v,%v", w.cursorX,
+				w.cursorY, x, y)
+		}
+	}
+
+	// soft wrap at word boundaries, scrolled to the cursor
+	w.render()
+	if line(0) != "world foo " || line(1) != "bar       " {
+		t.Fatalf("wrap: %q %q", line(0), line(1))
+	}
+	cursor(3, 1)
+
+	// vertical movement keeps the column and scrolls
+	ta.KeyHandler(Key{Action: ActionTextAreaUp})
+	cursor(3, 0)
+	ta.KeyHandler(Key{Action: ActionTextAreaUp})
+	cursor(3, 0)
+	if line(0) != "hello     " || ta.pos != 3 {
+		t.Fatalf("up: %q %v", line(0), ta.pos)
+	}
+	ta.KeyHandler(Key{Action: ActionTextAreaPageDown})
+	cursor(3, 1)
+	ta.KeyHandler(Key{Action: ActionEditEnd})
+	ta.KeyHandler(Key{Action: ActionTextAreaPageUp})
+	ta.KeyHandler(Key{Action: ActionTextAreaPageUp})
+	if ta.pos != 5 {
+		t.Fatalf("page up: %v", ta.pos)
+	}
+
+	// line breaks and typing
+	ta.KeyHandler(Key{Action: ActionTextAreaNewline})
+	ta.KeyHandler(Key{Ch: 'x'})
+	if ta.GetText() != "hello\nx world foo bar" {
+		t.Fatalf("newline: %q", ta.GetText())
+	}
+	cursor(1, 1)
+
+	// the submit key is configurable
+	enter, altEnter := []Key{{Key: KeyEnter}}, []Key{{Mod: ModAlt,
+		Key: KeyEnter}}
+	if a, _ := ta.Keymap().lookup(enter); a != ActionTextAreaNewline {
+		t.Fatalf("enter: %v", a)
+	}
+	if err := ta.SetSubmitKey("Enter"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := ta.Keymap().lookup(enter); a != ActionEditSubmit {
+		t.Fatalf("enter: %v", a)
+	}
+	if a, _ := ta.Keymap().lookup(altEnter); a != ActionTextAreaNewline {
+		t.Fatalf("alt-enter: %v", a)
+	}
+
+	// kill line only erases the line of the cursor
+	ta.SetValue("one\ntwo\nthree", false)
+	ta.KeyHandler(Key{Action: ActionTextAreaDown})
+	ta.KeyHandler(Key{Action: ActionEditRight})
+	ta.KeyHandler(Key{Action: ActionEditKillLine})
+	if ta.GetText() != "one\n\nthree" || ta.pos != 4 {
+		t.Fatalf("kill line: %q %v", ta.GetText(), ta.pos)
+	}
+	cursor(0, 1)
+}
+
+func TestTextAreaSmall(t *testing.T) {
+	w, done := newTestWindow(10, 2)
+	defer done()
+	one := w.AddTextArea(0, 0, 0, 1, "")
+	one.SetValue("a\nb\nc", true)
+	none := w.AddTextArea(0, 2, 0, 0, "")
+	none.SetValue("a\nb\nc", true)
+	w.render()
+
+	// a single line still pages
+	one.pos = 0
+	one.KeyHandler(Key{Action: ActionTextAreaPageDown})
+	if one.row() != 1 {
+		t.Fatalf("page down: row %v", one.row())
+	}
+	one.KeyHandler(Key{Action: ActionTextAreaPageUp})
+	if one.row() != 0 {
+		t.Fatalf("page up: row %v", one.row())
+	}
+
+	// without room there is nothing to page and the cursor is hidden
+	if none.trueH != 0 {
+		t.Fatalf("height: %v", none.trueH)
+	}
+	none.pos = 0
+	none.KeyHandler(Key{Action: ActionTextAreaPageDown})
+	if none.row() != 1 {
+		t.Fatalf("no room page down: row %v", none.row())
+	}
+	none.setCursor()
+	if w.cursorX != -1 || w.cursorY != -1 {
+		t.Fatalf("cursor: got %v,%v", w.cursorX, w.cursorY)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(3)
+	for _, line := range []string{"ls", "", "cd /tmp", "ls", "make", "ls"} {
+		h.Add(line)
+	}
+	if got := strings.Join(h.Lines(), ","); got != "cd /tmp,make,ls" {
+		t.Fatalf("lines: %q", got)
+	}
+	var b bytes.Buffer
+	if err := h.Save(&b); err != nil {
+		t.Fatal(err)
+	}
+	h = NewHistory(0)
+	if err := h.Load(&b); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(h.Lines(), ","); got != "cd /tmp,make,ls" {
+		t.Fatalf("load: %q", got)
+	}
+
+	w, done := newTestWindow(40, 1)
+	defer done()
+	e := w.AddEditText(0, 0, 0, "")
+	e.SetHistory(h)
+	typed := func(s string) {
+		for _, r := range s {
+			e.KeyHandler(Key{Ch: r})
+		}
+	}
+	text := func(want string) {
+		if e.GetText() != want {
+			t.Fatalf("got %q want %q", e.GetText(), want)
+		}
+	}
+
+	// recall keeps the draft
+	typed("dra")
+	e.KeyHandler(Key{Action: ActionEditHistoryPrevious})
+	text("ls")
+	e.KeyHandler(Key{Action: ActionEditHistoryPrevious})
+	text("make")
+	e.KeyHandler(Key{Action: ActionEditHistoryNext})
+	e.KeyHandler(Key{Action: ActionEditHistoryNext})
+	text("dra")
+
+	// reverse incremental search
+	e.KeyHandler(Key{Action: ActionEditSearch})
+	typed("m")
+	line := lines(w.Canvas())[0]
+	if !strings.HasPrefix(line, "(reverse-i-search)`m': make") {
+		t.Fatalf("prompt: %q", line)
+	}
+	typed("x")
+	if !e.failed || e.match != 1 {
+		t.Fatalf("failed search: %v %v", e.failed, e.match)
+	}
+	e.KeyHandler(Key{Action: ActionEditBackspace})
+	e.KeyHandler(Key{Action: ActionEditBackspace})
+	typed("/")
+	e.KeyHandler(Key{Action: ActionEditSubmit})
+	text("cd /tmp")
+
+	// esc restores the text, other keys accept and apply
+	e.KeyHandler(Key{Action: ActionEditSearch})
+	typed("ma")
+	e.KeyHandler(Key{Action: ActionEditCancel})
+	text("cd /tmp")
+	e.KeyHandler(Key{Action: ActionEditSearch})
+	typed("ma")
+	e.KeyHandler(Key{Action: ActionEditBackspace, Key: KeyBackspace2})
+	e.KeyHandler(Key{Action: ActionEditHome})
+	typed("x")
+	text("xmake")
+
+	// submitted lines are added
+	e.KeyHandler(Key{Action: ActionEditSubmit})
+	if got := strings.Join(h.Lines(), ","); got != "cd /tmp,make,ls,xmake" {
+		t.Fatalf("submit: %q", got)
+	}
+}
+
+func TestCompletionCycle(t *testing.T) {
+	w, done := newTestWindow(40, 1)
+	defer done()
+	e := w.AddEditText(0, 0, 0, "say hi al")
+	e.SetCompleter(CompleterFunc(func(text string, pos int) []string {
+		return []string{"alice", "alan"}
+	}), CompleteCycle)
+	e.SetWordBreaks(" ,")
+
+	for _, want := range []string{"say hi alice", "say hi alan",
+		"say hi al", "say hi alice"} {
+		e.KeyHandler(Key{Key: KeyTab, Action: ActionEditComplete})
+		if e.GetText() != want {
+			t.Fatalf("got %q want %q", e.GetText(), want)
+		}
+	}
+
+	// other keys accept the candidate
+	e.KeyHandler(Key{Ch: ','})
+	if e.GetText() != "say hi alice," || e.cands != nil {
+		t.Fatalf("accept: %q", e.GetText())
+	}
+}
+
+func TestEditReadline(t *testing.T) {
+	w, done := newTestWindow(40, 1)
+	defer done()
+	e := w.AddEditText(0, 0, 0, "")
+
+	// text and want mark the cursor with |
+	tests := []struct {
+		name string
+		text string
+		keys string
+		want string
+	}{
+		{"word left", "foo bar-baz|", "Alt-b", "foo bar-|baz"},
+		{"word left twice", "foo bar-baz|", "Alt-b Ctrl-Left", "foo |bar-baz"},
+		{"word left begin", "  |foo", "Alt-b", "|  foo"},
+		{"word right", "|foo bar", "Alt-f", "foo| bar"},
+		{"word right twice", "|foo bar", "Alt-f Ctrl-Right", "foo bar|"},
+		{"kill word backward", "cd /usr/lo|cal", "Ctrl-W", "cd |cal"},
+		{"kill word", "foo| bar baz", "Alt-d", "foo| baz"},
+		{"kill to end", "foo |bar", "Ctrl-K", "foo |"},
+		{"kill line", "foo |bar", "Ctrl-U", "|"},
+		{"yank", "foo |bar", "Ctrl-K Ctrl-A Ctrl-Y", "bar|foo "},
+		{"yank twice", "a|b", "Ctrl-K Ctrl-Y Ctrl-Y", "abb|"},
+		{"yank empty", "a|b", "Ctrl-Y", "a|b"},
+		{"consecutive kills", "one two three|", "Ctrl-W Ctrl-W Ctrl-Y",
+			"one two three|"},
+		{"mixed kills", "one two| three", "Alt-d Ctrl-W Ctrl-Y",
+			"one two three|"},
+		{"yank pop", "one two|", "Ctrl-W Left Ctrl-W Ctrl-Y Alt-y",
+			"two| "},
+		{"yank pop cycles", "a b|", "Ctrl-W Left Ctrl-W Ctrl-Y Alt-y Alt-y",
+			"a| "},
+		{"yank pop needs yank", "a b|", "Ctrl-W Ctrl-Y x Alt-y", "a bx|"},
+		{"transpose", "a|bc", "Ctrl-T", "ba|c"},
+		{"transpose end", "abc|", "Ctrl-T", "acb|"},
+		{"transpose begin", "|abc", "Ctrl-T", "|abc"},
+		{"redraw", "ab|c", "Ctrl-L", "ab|c"},
+	}
+	for _, test := range tests {
+		killRing = nil
+		i := strings.Index(test.text, "|")
+		e.SetValue(strings.Replace(test.text, "|", "", 1), false)
+		e.move(len([]rune(test.text[:i])))
+		e.last = ""
+		for _, f := range strings.Fields(test.keys) {
+			k, err := ParseKey(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			k.Action, _ = WidgetKeymap(WidgetEdit).lookup([]Key{k})
+			e.KeyHandler(k)
+		}
+		text := string(e.display)
+		got := text[:len(string(e.display[:e.index()]))] + "|" +
+			text[len(string(e.display[:e.index()])):]
+		if got != test.want {
+			t.Errorf("%v: got %q want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestEditUndo(t *testing.T) {
+	w, done := newTestWindow(40, 1)
+	defer done()
+	e := w.AddEditText(0, 0, 0, "")
+
+	key := func(keys string) {
+		for _, f := range strings.Fields(keys) {
+			k, err := ParseKey(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			k.Action, _ = WidgetKeymap(WidgetEdit).lookup([]Key{k})
+			e.KeyHandler(k)
+		}
+	}
+	want := func(text string, pos int) {
+		if e.GetText() != text || e.index() != pos {
+			t.Fatalf("got %q %v want %q %v", e.GetText(), e.index(),
+				text, pos)
+		}
+	}
+
+	// typed runes are undone in one step, cursor motion ends the step
+	key("h i Space t h e r e Left a")
+	want("hi therae", 8)
+	key("Ctrl-_")
+	want("hi there", 7)
+	key("Ctrl-Z")
+	want("", 0)
+	key("Ctrl-Z")
+	want("", 0)
+	key("Alt-_")
+	want("hi there", 7)
+	key("Alt-z")
+	want("hi therae", 8)
+	key("Alt-z")
+	want("hi therae", 8)
+
+	// kills are undone and a change forgets the redo
+	key("Ctrl-U")
+	want("", 0)
+	key("Ctrl-_")
+	want("hi therae", 8)
+	key("Ctrl-A Ctrl-K x Ctrl-_ Ctrl-_")
+	want("hi therae", 0)
+	key("Alt-z x Alt-z")
+	want("x", 1)
+
+	// depth
+	e.SetValue("", true)
+	e.SetUndoDepth(2)
+	key("a Left b Left c Left d")
+	want("dcba", 1)
+	key("Ctrl-_ Ctrl-_ Ctrl-_")
+	want("ba", 0)
+	e.SetUndoDepth(0)
+	key("x Ctrl-_")
+	want("xba", 1)
+}
+
+func TestEditMasked(t *testing.T) {
+	killRing = nil
+	w, done := newTestWindow(10, 1)
+	defer done()
+	target := ""
+	e := w.AddEdit(0, 0, 10, &target)
+	e.SetMasked(true, '*')
+	w.render()
+
+	line := func() string { return lines(w.Canvas())[0] }
+	wiped := func() bool {
+		for _, r := range e.display[:cap(e.display)] {
+			if r != 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	var (
+		changed   []string
+		submitted []byte
+	)
+	e.OnChange(func(s string) { changed = append(changed, s) })
+	e.OnSubmit(func(s string) bool {
+		if s != "" {
+			t.Fatalf("submit: got %q", s)
+		}
+		submitted = e.Bytes()
+		return true
+	})
+
+	for _, r := range "sécret" {
+		e.KeyHandler(Key{Ch: r})
+	}
+	e.KeyHandler(Key{Action: ActionEditLeft})
+	e.KeyHandler(Key{Action: ActionEditBackspace})
+	if line() != "*****     " || w.cursorX != 4 {
+		t.Fatalf("render: got %q %v", line(), w.cursorX)
+	}
+	if target != "" || e.GetText() != "" || e.Describe() != "edit: , masked" {
+		t.Fatalf("text leaked: %q %q %q", target, e.GetText(), e.Describe())
+	}
+	for _, s := range changed {
+		if s != "" {
+			t.Fatalf("change: got %q", s)
+		}
+	}
+	if len(changed) != 7 || len(e.undo) != 0 || len(killRing) != 0 {
+		t.Fatalf("changes %v undo %v kills %v", len(changed),
+			len(e.undo), len(killRing))
+	}
+	if tail := e.display[len(e.display):cap(e.display)]; len(tail) == 0 ||
+		tail[0] != 0 {
+		t.Fatalf("deleted rune left behind: %q", string(tail))
+	}
+
+	// submit hands out the text and wipes it
+	e.KeyHandler(Key{Action: ActionEditSubmit})
+	if string(submitted) != "sécrt" {
+		t.Fatalf("bytes: got %q", submitted)
+	}
+	if len(e.display) != 0 || !wiped() || line() != "          " {
+		t.Fatalf("not wiped: %q %q", string(e.display[:cap(e.display)]),
+			line())
+	}
+
+	// no mask displays nothing, clearing wipes
+	e.SetMasked(true, 0)
+	for _, r := range "abc" {
+		e.KeyHandler(Key{Ch: r})
+	}
+	if line() != "          " || w.cursorX != 0 {
+		t.Fatalf("no mask: got %q %v", line(), w.cursorX)
+	}
+	e.KeyHandler(Key{Key: KeyCtrlU, Action: ActionEditKillLine})
+	if len(e.display) != 0 || !wiped() || len(killRing) != 0 {
+		t.Fatalf("kill: %q", string(e.display[:cap(e.display)]))
+	}
+
+	// the target is never written
+	e.SetText(&target, true)
+	e.SetValue("secret", true)
+	if target != "" || string(e.Bytes()) != "secret" {
+		t.Fatalf("target: got %q %q", target, e.Bytes())
+	}
+
+	// without OnSubmit the text is left for the application
+	e.OnSubmit(nil)
+	if e.KeyHandler(Key{Action: ActionEditSubmit}) {
+		t.Fatal("submit consumed")
+	}
+	if string(e.Bytes()) != "secret" {
+		t.Fatalf("submit wiped: %q", e.Bytes())
+	}
+	e.Wipe()
+	if len(e.display) != 0 || !wiped() {
+		t.Fatalf("wipe: %q", string(e.display[:cap(e.display)]))
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// NewTerminalTTY returns a Terminal that drives the terminal device f, i.e.
+// the slave side of a pty that was allocated for an SSH session.  The device
+// is switched to raw mode, Deinit restores the previous mode.  The initial
+// size is read from the device, use SyncSize after the size changed.  The
+// term argument is the terminfo name of the terminal.
+func NewTerminalTTY(f *os.File, term string) (*Terminal, error) {
+	fd := int(f.Fd())
+	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return nil, err
+	}
+	t, err := NewTerminal(f, term, int(ws.Col), int(ws.Row))
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, err
+	}
+	raw := *saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP |
+		unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG |
+		unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err = unix.IoctlSetTermios(fd, unix.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+
+	t.fd = fd
+	t.restore = func() {
+		_ = unix.IoctlSetTermios(fd, unix.TCSETS, saved)
+	}
+	return t, nil
+}
+
+// SyncSize reads the size of the terminal device again and reports it like
+// Resize does, i.e. after SIGWINCH.  It only applies to a Terminal that was
+// returned by NewTerminalTTY.
+// SyncSize may be called from any go routine.
+func (t *Terminal) SyncSize() error {
+	if t.fd < 0 {
+		return ErrNotTTY
+	}
+	ws, err := unix.IoctlGetWinsize(t.fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return err
+	}
+	t.Resize(int(ws.Col), int(ws.Row))
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPty returns the master and slave side of a new pty.
+func openPty(t *testing.T) (*os.File, *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pty: %v", err)
+	}
+	fd := int(master.Fd())
+	if err = unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		t.Fatal(err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		t.Fatal(err)
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n),
+		os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		t.Fatal(err)
+	}
+	return master, slave
+}
+
+func TestTerminalTTY(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+	r := newRemote(master)
+
+	setSize := func(width, height int) {
+		err := unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ,
+			&unix.Winsize{Col: uint16(width), Row: uint16(height)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	lflag := func() uint32 {
+		tio, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tio.Lflag
+	}
+
+	setSize(30, 8)
+	term, err := NewTerminalTTY(slave, "xterm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lflag()&(unix.ICANON|unix.ECHO) != 0 {
+		t.Fatal("not in raw mode")
+	}
+	if width, height := term.size(); width != 30 || height != 8 {
+		t.Fatalf("size: got %vx%v", width, height)
+	}
+	if err = InitTerminal(term); err != nil {
+		t.Fatal(err)
+	}
+	defer Deinit()
+
+	Focus(NewWindow(&funcWindow{init: helloWorld}))
+	r.waitFor(t, "hello")
+
+	// raw mode delivers keys without a line break and leaves CR alone
+	r.typeKey(t, "x", Key{Ch: 'x'})
+	r.typeKey(t, "\r", Key{Key: KeyEnter})
+
+	// the new size is read from the device
+	setSize(50, 12)
+	if err = term.SyncSize(); err != nil {
+		t.Fatal(err)
+	}
+	waitQueue(t, "resize", func() bool { return maxX == 50 && maxY == 12 })
+
+	// the previous mode is restored
+	Deinit()
+	if lflag()&unix.ICANON == 0 {
+		t.Fatal("mode not restored")
+	}
+}
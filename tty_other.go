@@ -0,0 +1,23 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package ttk
+
+import "os"
+
+// NewTerminalTTY returns a Terminal that drives the terminal device f.  Raw
+// mode is only implemented on Linux, elsewhere ErrNotTTY is returned; put the
+// device in raw mode and use NewTerminal instead.
+func NewTerminalTTY(f *os.File, term string) (*Terminal, error) {
+	return nil, ErrNotTTY
+}
+
+// SyncSize reads the size of the terminal device again.  It is only
+// implemented on Linux.
+func (t *Terminal) SyncSize() error {
+	return ErrNotTTY
+}
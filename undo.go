@@ -0,0 +1,78 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk
+
+// Undo actions.  They are bound in the WidgetEdit keymap.
+const (
+	ActionEditUndo = "edit.undo" // revert last change
+	ActionEditRedo = "edit.redo" // reapply reverted change
+)
+
+// defaultUndoDepth is the number of changes that can be undone by default.
+const defaultUndoDepth = 100
+
+// editState is the text and cursor position of an edit before a change.
+type editState struct {
+	text []rune
+	pos  int
+}
+
+// saveUndo records the state before a change.  Changes made by typing are
+// recorded once until another key is pressed so that a typed word is undone
+// in one step.  Recording a change forgets the changes that can be redone.
+// saveUndo shall be called from queue context.
+func (e *Edit) saveUndo(typing bool) {
+	if typing && e.typing {
+		return
+	}
+	e.typing = typing
+	e.redo = nil
+	if e.undoDepth <= 0 || e.masked {
+		return
+	}
+	e.undo = append(e.undo, e.state())
+	if len(e.undo) > e.undoDepth {
+		e.undo = append([]editState(nil), e.undo[len(e.undo)-e.undoDepth:]...)
+	}
+}
+
+// state returns a copy of the text and the cursor position.
+func (e *Edit) state() editState {
+	return editState{
+		text: append([]rune(nil), e.display...),
+		pos:  e.index(),
+	}
+}
+
+// restore pops the last state off from and makes it the edit text.  The
+// current state is pushed onto to.
+// restore shall be called from queue context.
+func (e *Edit) restore(from, to *[]editState) {
+	if len(*from) == 0 {
+		return
+	}
+	s := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, e.state())
+	e.display = s.text
+	e.move(s.pos)
+	e.changed()
+}
+
+// SetUndoDepth sets the number of changes that can be undone, the oldest
+// changes are forgotten first.  A depth of 0 disables undo.  The default is
+// 100.
+// SetUndoDepth shall be called from queue context.
+func (e *Edit) SetUndoDepth(depth int) {
+	e.undoDepth = depth
+	if depth <= 0 {
+		e.undo = nil
+		e.redo = nil
+		return
+	}
+	if len(e.undo) > depth {
+		e.undo = append([]editState(nil), e.undo[len(e.undo)-depth:]...)
+	}
+}
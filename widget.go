@@ -6,15 +6,27 @@ package ttk
 
 import (
 	"errors"
-
-	"github.com/gdamore/tcell/termbox"
+	"sync"
 )
 
 // Widget is the base structure of all widgets.
 type Widget struct {
-	w *Window
-	x int
-	y int
+	w        *Window
+	x        int
+	y        int
+	rect     Rect // area assigned by the window layout
+	placed   bool // true if rect is set
+	keymap   *Keymap
+	disabled bool   // skipped when moving focus
+	name     string // announced in linear mode
+}
+
+// Rect is a rectangular area in window coordinates.
+type Rect struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
 }
 
 var (
@@ -24,11 +36,40 @@ var (
 	// rules.
 	ErrWidgetNotRegistered = errors.New("widget not registered")
 
+	// ErrWidgetRegistered is generated when a widget is registered under
+	// an id that is already in use.
+	ErrWidgetRegistered = errors.New("widget already registered")
+
 	// registeredWidgets contains the registered widgets types.
-	registeredWidgets = make(map[string]func(*Window, int, int) (Widgeter,
-		error))
+	registeredWidgets = make(map[string]WidgetConstructor)
+	widgetMtx         sync.Mutex
 )
 
+// WidgetConstructor creates a widget in the provided window at the anchor
+// point.  By convention it is exported as NewXXX, i.e. NewLabel.
+type WidgetConstructor func(w *Window, x, y int) (Widgeter, error)
+
+// RegisterWidget registers a widget type under the provided id so that it can
+// be added to windows with AddWidget.  Widgets defined outside of ttk must be
+// registered before use, typically from an init function.
+func RegisterWidget(id string, ctor WidgetConstructor) error {
+	widgetMtx.Lock()
+	defer widgetMtx.Unlock()
+	if _, found := registeredWidgets[id]; found {
+		return ErrWidgetRegistered
+	}
+	registeredWidgets[id] = ctor
+	return nil
+}
+
+// lookupWidget returns the constructor of a registered widget.
+func lookupWidget(id string) (WidgetConstructor, bool) {
+	widgetMtx.Lock()
+	defer widgetMtx.Unlock()
+	ctor, found := registeredWidgets[id]
+	return ctor, found
+}
+
 type Visibility int
 
 const (
@@ -45,10 +86,125 @@ type Widgeter interface {
 	Focus()                           // Focus on widget
 	Render()                          // Render the widget
 	Resize()                          // Resize the widget
-	KeyHandler(termbox.Event) bool    // handle key strokes
+	KeyHandler(Key) bool              // handle key strokes
 	Visibility(Visibility) Visibility // show/hide widget
 }
 
+// widgetBase is implemented by widgets that embed Widget.
+type widgetBase interface {
+	base() *Widget
+}
+
+// base returns the embedded Widget.
+func (w *Widget) base() *Widget {
+	return w
+}
+
+// Enabled returns false if the widget was disabled.
+// Enabled shall be called from queue context.
+func (w *Widget) Enabled() bool {
+	return !w.disabled
+}
+
+// SetEnabled enables or disables the widget.  Disabled widgets are skipped
+// when focus moves.  A focused widget that is disabled loses focus on the
+// next render.
+// SetEnabled shall be called from queue context.
+func (w *Widget) SetEnabled(enabled bool) {
+	w.disabled = !enabled
+}
+
+// Name returns the name that describes the widget in linear mode.
+// Name shall be called from queue context.
+func (w *Widget) Name() string {
+	return w.name
+}
+
+// SetName sets the name that describes the widget in linear mode, i.e. the
+// text of the label in front of an edit.
+// SetName shall be called from queue context.
+func (w *Widget) SetName(name string) {
+	w.name = name
+}
+
+// Window returns the window that contains the widget.
+func (w *Widget) Window() *Window {
+	return w.w
+}
+
+// Anchor returns the anchor point the widget was created with.
+func (w *Widget) Anchor() (int, int) {
+	return w.x, w.y
+}
+
+// window returns the window that contains the widget.
+func (w *Widget) window() *Window {
+	return w.w
+}
+
+// position returns the window coordinates of the widget.  Widgets that
+// resolve their coordinates on Resize shall override it.
+func (w *Widget) position() (int, int) {
+	return w.x, w.y
+}
+
+// setRect assigns the area the widget is drawn in.  It overrides the anchor
+// point and size the widget was created with.
+// setRect shall be called from queue context.
+func (w *Widget) setRect(r Rect) {
+	w.rect = r
+	w.placed = true
+}
+
+// clearRect returns the widget to placing itself.
+// clearRect shall be called from queue context.
+func (w *Widget) clearRect() {
+	w.placed = false
+}
+
+// Bounds returns the area the widget is drawn in.  If the widget is part of a
+// layout the assigned area is returned.  Otherwise the anchor point and the
+// provided size are shorthand for a layout of the window: a negative x or y
+// is counted from the right or bottom edge, -1 being the last column or line,
+// and a width or height smaller than 1 extends to that many cells before the
+// edge.  The area is clipped to the window.
+// Bounds shall be called from queue context.
+func (w *Widget) Bounds(width, height int) Rect {
+	if w.placed {
+		return w.rect
+	}
+
+	var r Rect
+	r.X, r.Width = anchorAxis(w.x, width, w.w.x)
+	r.Y, r.Height = anchorAxis(w.y, height, w.w.y)
+	return r
+}
+
+// MinSizer is an optional interface that widgets implement to declare the
+// smallest window size they can be rendered in.  While the window is smaller
+// than what any of its widgets require it displays a "terminal too small"
+// screen instead.  MinSize is called from queue context so be careful to not
+// use blocking calls.
+type MinSizer interface {
+	MinSize() (int, int)
+}
+
+// MinBounds returns the smallest window size in which Bounds, called with the
+// same width and height, returns an area of at least minWidth by minHeight
+// cells that fits the window.  Widgets that are placed by the window layout
+// return 0, 0 since the layout decides their size.
+// MinBounds shall be called from queue context.
+func (w *Widget) MinBounds(width, height, minWidth, minHeight int) (int, int) {
+	if w.placed {
+		return 0, 0
+	}
+	lx, _ := anchorLayout(w.x, width, minWidth)
+	ly, _ := anchorLayout(w.y, height, minHeight)
+	x, _ := lx.minSize()
+	y, _ := ly.minSize()
+	return x, y
+}
+
 // MakeWidget creates a generic Widget structure.
 func MakeWidget(w *Window, x, y int) Widget {
 	return Widget{
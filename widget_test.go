@@ -0,0 +1,179 @@
+// Copyright (c) 2016 Company 0, LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ttk_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/companyzero/ttk"
+)
+
+// WidgetMeter uniquely identifies the meter widget.
+const WidgetMeter = "meter"
+
+func init() {
+	err := ttk.RegisterWidget(WidgetMeter, NewMeter)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Meter is an example of a widget that is defined outside of ttk.  It shows
+// a value as a bar that can be changed with + and -.
+type Meter struct {
+	ttk.Widget
+	r          ttk.Rect
+	width      int
+	value      int
+	max        int
+	visibility ttk.Visibility
+}
+
+// NewMeter is the Meter initializer.
+func NewMeter(w *ttk.Window, x, y int) (ttk.Widgeter, error) {
+	return &Meter{
+		Widget: ttk.MakeWidget(w, x, y),
+		max:    10,
+	}, nil
+}
+
+// AddMeter adds a meter to the window.  AddMeter must be called from queue.
+func AddMeter(w *ttk.Window, x, y, width, value int) *Meter {
+	widget, err := w.AddWidget(WidgetMeter, x, y)
+	if err != nil {
+		panic(err)
+	}
+	m := widget.(*Meter)
+	m.width = width
+	m.value = value
+	m.Resize()
+	return m
+}
+
+func (m *Meter) CanFocus() bool {
+	return true
+}
+
+func (m *Meter) Focus() {
+	m.Window().Canvas().SetCursor(m.r.X, m.r.Y)
+}
+
+func (m *Meter) Resize() {
+	m.r = m.Bounds(m.width, 1)
+}
+
+func (m *Meter) Render() {
+	c := m.Window().Canvas().Clip(m.r)
+	width, height := c.Size()
+	s := c.DefaultStyle()
+	if m.visibility == ttk.VisibilityHide {
+		c.Fill(ttk.Rect{Width: width, Height: height}, ' ', s)
+		return
+	}
+	filled := width * m.value / m.max
+	c.Fill(ttk.Rect{Width: filled, Height: height}, '#', s)
+	c.Fill(ttk.Rect{X: filled, Width: width - filled, Height: height},
+		'.', s)
+}
+
+func (m *Meter) KeyHandler(k ttk.Key) bool {
+	switch {
+	case k.Ch == '+' && m.value < m.max:
+		m.value++
+	case k.Ch == '-' && m.value > 0:
+		m.value--
+	default:
+		return false
+	}
+	m.Render()
+	return true
+}
+
+func (m *Meter) Visibility(op ttk.Visibility) ttk.Visibility {
+	if op != ttk.VisibilityGet {
+		m.visibility = op
+	}
+	return m.visibility
+}
+
+type meterWindow struct {
+	m *Meter
+}
+
+func (mw *meterWindow) Init(w *ttk.Window) {
+	mw.m = AddMeter(w, 2, 1, 10, 5)
+}
+
+func (mw *meterWindow) Render(w *ttk.Window) {}
+
+func (mw *meterWindow) KeyHandler(w *ttk.Window, k ttk.Key) {}
+
+// line returns the text of a window line.
+func line(w *ttk.Window, y int) string {
+	c := make(chan string)
+	ttk.Queue(func() {
+		c <- ttk.Lines(w.Canvas())[y]
+	})
+	return <-c
+}
+
+func TestCustomWidget(t *testing.T) {
+	if ttk.RegisterWidget(WidgetMeter, NewMeter) != ttk.ErrWidgetRegistered {
+		t.Fatalf("expected duplicate registration to fail")
+	}
+
+	local, far := net.Pipe()
+	defer local.Close()
+	defer far.Close()
+	go io.Copy(ioutil.Discard, far)
+
+	term, err := ttk.NewTerminal(local, "xterm", 20, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ttk.InitTerminal(term)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ttk.Deinit()
+
+	mw := &meterWindow{}
+	w := ttk.NewWindow(mw)
+	ttk.Focus(w)
+	want := "  #####.....        "
+	if got := line(w, 1); got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	// keys reach the focused custom widget
+	_, err = far.Write([]byte("+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "  ######....        "
+	for i := 0; line(w, 1) != want; i++ {
+		if i > 100 {
+			t.Fatalf("got %q want %q", line(w, 1), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// ExampleRegisterWidget shows how a widget that is defined outside of ttk is
+// registered.
+func ExampleRegisterWidget() {
+	// typically done from init
+	err := ttk.RegisterWidget("example", NewMeter)
+	if err != nil {
+		panic(err)
+	}
+
+	// Windower.Init then adds it with w.AddWidget("example", x, y) or
+	// with a helper such as AddMeter.
+}
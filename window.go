@@ -7,19 +7,33 @@ package ttk
 import (
 	"fmt"
 	"unicode/utf8"
-
-	"github.com/gdamore/tcell/termbox"
 )
 
 // Window contains a window context.
 type Window struct {
-	id           int        // window id
-	x            int        // max x
-	y            int        // max y
-	mgr          Windower   // key handler + renderer
-	backingStore []Cell     // output buffer
-	widgets      []Widgeter // window widgets
-	focus        int        // currently focused widget
+	id           int         // window id
+	x            int         // max x
+	y            int         // max y
+	originX      int         // screen column of window column 0
+	originY      int         // screen row of window row 0
+	cursorX      int         // cursor x, -1 if hidden
+	cursorY      int         // cursor y, -1 if hidden
+	cursorShape  CursorShape // cursor shape, reset by setCursor
+	mgr          Windower    // key handler + renderer
+	backingStore []Cell      // output buffer
+	widgets      []Widgeter  // window widgets
+	focus        int         // currently focused widget
+	overlay      *Overlay    // placement, nil for regular windows
+	layout       *Layout     // widget layout, nil if widgets place themselves
+	title        string      // application provided title
+	activity     Activity    // unseen activity while not focused
+	keymap       *Keymap     // window scope key bindings
+	tabOrder     []Widgeter  // focus order, nil means widgets order
+	minX         int         // application provided minimum width
+	minY         int         // application provided minimum height
+	needX        int         // required width, set on resize
+	needY        int         // required height, set on resize
+	tooSmall     bool        // window is smaller than required
 }
 
 // Windower interface.  Each window has a Windower interface associated with
@@ -35,7 +49,7 @@ type Windower interface {
 // should call the non-generic type asserted call (i.e. AddLabel).
 // AddWidget shall be called from queue context.
 func (w *Window) AddWidget(id string, x, y int) (Widgeter, error) {
-	rw, found := registeredWidgets[id]
+	rw, found := lookupWidget(id)
 	if !found {
 		return nil, ErrWidgetNotRegistered
 	}
@@ -43,54 +57,139 @@ func (w *Window) AddWidget(id string, x, y int) (Widgeter, error) {
 	if err != nil {
 		return nil, err
 	}
+	if b, ok := widget.(widgetBase); ok {
+		b.base().keymap = &Keymap{parent: WidgetKeymap(id)}
+	}
 	w.widgets = append(w.widgets, widget)
 	return widget, err
 }
 
+// RemoveWidget removes the widget from the window and clears the area it
+// occupied.  If the widget had focus the next widget that can focus is
+// focused.  Widgets clear their area when rendered while hidden.
+// RemoveWidget shall be called from queue context.
+func (w *Window) RemoveWidget(widget Widgeter) {
+	for i, v := range w.widgets {
+		if v != widget {
+			continue
+		}
+
+		widget.Visibility(VisibilityHide)
+		widget.Render()
+		if i == w.focus {
+			// focus on the next widget or none
+			w.focusStep(1)
+			if w.focus == i {
+				w.focusIndex(-1)
+			}
+		}
+
+		copy(w.widgets[i:], w.widgets[i+1:])
+		w.widgets[len(w.widgets)-1] = nil
+		w.widgets = w.widgets[:len(w.widgets)-1]
+		if w.layout != nil {
+			w.layout.remove(widget)
+		}
+		for _, v := range w.widgets {
+			if f, ok := v.(*Frame); ok && f.content != nil {
+				f.content.remove(widget)
+			}
+		}
+
+		if i < w.focus {
+			w.focus--
+		}
+		for k, v := range w.tabOrder {
+			if v == widget {
+				w.tabOrder = append(w.tabOrder[:k],
+					w.tabOrder[k+1:]...)
+				break
+			}
+		}
+		if len(w.tabOrder) == 0 {
+			// back to the order in which the widgets were added
+			w.tabOrder = nil
+		}
+		return
+	}
+}
+
 // printf prints into the backend buffer.
 // This will not show immediately.
 // printf shall be called from queue context.
-func (w *Window) printf(x, y int, a Attributes, format string,
+func (w *Window) printf(x, y int, s Style, format string,
 	args ...interface{}) {
-	out := fmt.Sprintf(format, args...)
-	xx := 0
+	w.print(x, y, w.x-x, s, fmt.Sprintf(format, args...))
+}
+
+// print prints out into the backend buffer clipped to width cells and to the
+// window and returns the number of cells used.  Escape sequences understood
+// by DecodeColor change the style.  The printed cells are reordered with the
+// Unicode Bidirectional Algorithm so right to left text displays properly;
+// print padding separately since trailing whitespace takes the direction of
+// the text.
+// print shall be called from queue context.
+func (w *Window) print(x, y, width int, s Style, out string) int {
+	if y < 0 || y >= w.y || x < 0 {
+		return 0
+	}
+	if x+width > w.x {
+		width = w.x - x
+	}
+	cells := make([]Cell, 0, len(out))
 	c := Cell{}
-	c.Fg = a.Fg
-	c.Bg = a.Bg
-	mx := w.x - x
+	c.Style = s
 	var rw int
 	for i := 0; i < len(out); i += rw {
-		if x+xx+1 > mx {
+		if len(cells) >= width {
 			break
 		}
 
-		v, width := utf8.DecodeRuneInString(out[i:])
+		v, size := utf8.DecodeRuneInString(out[i:])
 		if v == '\x1b' {
 			// see if we understand this escape seqeunce
 			cc, skip, err := DecodeColor(out[i:])
 			if err == nil {
-				c.Fg = cc.Fg
-				c.Bg = cc.Bg
+				c.Style = *cc
 				rw = skip
 				continue
 
 			}
 		}
 
-		rw = width
+		rw = size
 		c.Ch = v
+		cells = append(cells, c)
+	}
+	for xx, c := range bidiCells(cells) {
 		w.setCell(x+xx, y, c)
-		xx++
 	}
+	return len(cells)
 }
 
 // setCell sets the content of the window cell at the x and y coordinate.
 // setCell shall be called from queue context.
 func (w *Window) setCell(x, y int, c Cell) {
-	c.dirty = true
 	w.backingStore[x+(y*w.x)] = c
 }
 
+// setCursor sets the window cursor at the x and y coordinate.  The cursor is
+// displayed on flush if the window receives keyboard input.  Use -1, -1 to
+// hide the cursor.  The cursor shape returns to the terminal default.
+// setCursor shall be called from queue context.
+func (w *Window) setCursor(x, y int) {
+	w.cursorX = x
+	w.cursorY = y
+	w.cursorShape = CursorDefault
+}
+
+// setCursorShape sets the shape of the window cursor.  It shall be called
+// after setCursor.
+// setCursorShape shall be called from queue context.
+func (w *Window) setCursorShape(s CursorShape) {
+	w.cursorShape = s
+}
+
 // getCell returns the content of the window cell at the x and y coordinate.
 // getCell shall be called from queue context.
 func (w *Window) getCell(x, y int) *Cell {
@@ -98,12 +197,37 @@ func (w *Window) getCell(x, y int) *Cell {
 	return c
 }
 
-// resize sets new x and y maxima.
+// resize sets new x and y maxima.  The backing store is only reallocated if
+// the size actually changed.
 // resize shall be called from queue context.
 func (w *Window) resize(x, y int) {
-	w.x = x
-	w.y = y
-	w.backingStore = make([]Cell, x*y)
+	if w.x != x || w.y != y || len(w.backingStore) != x*y {
+		w.x = x
+		w.y = y
+		w.backingStore = make([]Cell, x*y)
+	}
+
+	// widgets that are not in the layout place themselves
+	for _, widget := range w.widgets {
+		if r, ok := widget.(rectSetter); ok {
+			r.clearRect()
+		}
+	}
+	if w.layout != nil {
+		w.layout.layout(0, 0, x, y)
+	}
+	for _, widget := range w.widgets {
+		if c, ok := widget.(container); ok {
+			c.place()
+		}
+	}
+
+	// widgets are not resized while the window is too small
+	w.needX, w.needY = w.minSize()
+	w.tooSmall = x < w.needX || y < w.needY
+	if w.tooSmall {
+		return
+	}
 
 	// iterate over widgets
 	for _, widget := range w.widgets {
@@ -111,9 +235,78 @@ func (w *Window) resize(x, y int) {
 	}
 }
 
+// SetMinSize sets the smallest size the window can be rendered in.  The
+// minimum sizes of the widgets are taken into account as well.  While the
+// window is smaller a "terminal too small" screen is displayed instead and
+// widgets do not receive keys.  This will not be displayed immediately.
+// SetMinSize shall be called from queue context.
+func (w *Window) SetMinSize(width, height int) {
+	w.minX = width
+	w.minY = height
+}
+
+// minSize returns the smallest size the window and its widgets can be
+// rendered in.
+// minSize shall be called from queue context.
+func (w *Window) minSize() (int, int) {
+	x, y := w.minX, w.minY
+	if w.layout != nil {
+		lx, ly := w.layout.minSize()
+		if lx > x {
+			x = lx
+		}
+		if ly > y {
+			y = ly
+		}
+	}
+	for _, widget := range w.widgets {
+		m, ok := widget.(MinSizer)
+		if !ok {
+			continue
+		}
+		wx, wy := m.MinSize()
+		if wx > x {
+			x = wx
+		}
+		if wy > y {
+			y = wy
+		}
+	}
+	return x, y
+}
+
+// renderTooSmall replaces the window contents with a message that states
+// the required size.
+// renderTooSmall shall be called from queue context.
+func (w *Window) renderTooSmall() {
+	c := w.Canvas()
+	c.Clear(defaultStyle())
+	c.SetCursor(-1, -1)
+	text := fmt.Sprintf("terminal too small (need %vx%v)", w.needX, w.needY)
+	x := (w.x - len(text)) / 2
+	if x < 0 {
+		x = 0
+	}
+	c.Print(x, w.y/2, defaultStyle(), "%v", text)
+}
+
+// SetLayout assigns the area of all widgets in the layout on every resize.
+// Widgets that are not part of the layout keep placing themselves.  The
+// widgets are resized immediately.
+// SetLayout shall be called from queue context.
+func (w *Window) SetLayout(l *Layout) {
+	w.layout = l
+	w.resize(w.x, w.y)
+}
+
 // render calls the user provided Render and than renders the widgets in the
 // window.
 func (w *Window) render() {
+	if w.tooSmall {
+		w.renderTooSmall()
+		return
+	}
+
 	w.mgr.Render(w)
 
 	// iterate over widgets
@@ -125,55 +318,137 @@ func (w *Window) render() {
 	w.focusWidget()
 }
 
-// focusWidget focuses on the current widget.  If focus is -1 it'll focus on
-// the first available widget.
-// focusWidget shall be called from queue context.
-func (w *Window) focusWidget() {
-	setCursor(-1, -1) // hide
-	if w.focus < 0 {
-		for i, widget := range w.widgets {
-			if widget.CanFocus() {
-				w.focus = i
-				widget.Focus()
-				return
-			}
+// Blurrer is an optional interface that widgets implement to be notified
+// when they lose focus, i.e. to change their look.  Blur is called from queue
+// context so be careful to not use blocking calls.
+type Blurrer interface {
+	Blur()
+}
+
+// blur notifies the focused widget that it loses focus because another window
+// is focused or the window is closed.  The widget remains the focused widget
+// of the window.
+// blur shall be called from queue context.
+func (w *Window) blur() {
+	if b, ok := w.focusedWidget().(Blurrer); ok {
+		b.Blur()
+	}
+}
+
+// focusable returns true if the widget can focus and is neither hidden nor
+// disabled.
+// focusable shall be called from queue context.
+func focusable(widget Widgeter) bool {
+	if !widget.CanFocus() || widget.Visibility(VisibilityGet) == VisibilityHide {
+		return false
+	}
+	if b, ok := widget.(widgetBase); ok && b.base().disabled {
+		return false
+	}
+	return true
+}
+
+// index returns the index of the widget in the window or -1 if it is not
+// part of the window.
+func (w *Window) index(widget Widgeter) int {
+	for i, v := range w.widgets {
+		if v == widget {
+			return i
 		}
-		// nothing to do
-		return
 	}
+	return -1
+}
 
-	// make sure we are in bounds
-	if w.focus > len(w.widgets) {
-		// this really should not happen
-		return
+// order returns the widgets in tab order.
+func (w *Window) order() []Widgeter {
+	if w.tabOrder != nil {
+		return w.tabOrder
 	}
+	return w.widgets
+}
 
-	w.widgets[w.focus].Focus()
+// focusWatcher is implemented by widgets that depend on which widget has
+// focus, i.e. Frame.
+type focusWatcher interface {
+	focusChanged()
 }
 
-// focusNext focuses on the next available widget.
-// focusNext shall be called from queue context.
-func (w *Window) focusNext() {
-	if w.focus < 0 {
-		w.focusWidget()
-		return
+// focusIndex focuses on the widget at index i, -1 removes focus.  The widget
+// that loses focus is blurred.
+// focusIndex shall be called from queue context.
+func (w *Window) focusIndex(i int) {
+	changed := i != w.focus
+	if prev := w.focusedWidget(); prev != nil && changed {
+		if b, ok := prev.(Blurrer); ok {
+			b.Blur()
+		}
+	}
+	w.setCursor(-1, -1) // hide
+	w.focus = i
+	if changed {
+		for _, widget := range w.widgets {
+			if fw, ok := widget.(focusWatcher); ok {
+				fw.focusChanged()
+			}
+		}
+	}
+	if widget := w.focusedWidget(); widget != nil {
+		widget.Focus()
+		if changed && w == inputWindow() {
+			announceWidget(widget)
+		}
 	}
+}
 
-	// find next widget
-	for i, widget := range w.widgets[w.focus+1:] {
-		if !widget.CanFocus() {
-			continue
+// focusStep moves focus to the next focusable widget in tab order, or the
+// previous one if dir is negative, wrapping around at the ends.  Without a
+// focused widget it starts at the first or the last one.
+// focusStep shall be called from queue context.
+func (w *Window) focusStep(dir int) {
+	order := w.order()
+	cur := -1
+	if widget := w.focusedWidget(); widget != nil {
+		for i, v := range order {
+			if v == widget {
+				cur = i
+			}
 		}
+	}
 
-		setCursor(-1, -1) // hide
-		w.focus = i + w.focus + 1
-		widget.Focus()
+	for n := 1; n <= len(order); n++ {
+		var i int
+		switch {
+		case cur >= 0:
+			i = ((cur+dir*n)%len(order) + len(order)) % len(order)
+		case dir < 0:
+			i = len(order) - n
+		default:
+			i = n - 1
+		}
+		if focusable(order[i]) {
+			w.focusIndex(w.index(order[i]))
+			return
+		}
+	}
+}
+
+// focusWidget focuses on the current widget.  If there is none, or it can no
+// longer focus, it'll focus on the first available widget.
+// focusWidget shall be called from queue context.
+func (w *Window) focusWidget() {
+	w.setCursor(-1, -1) // hide
+	widget := w.focusedWidget()
+	if widget == nil || !focusable(widget) {
+		w.focusStep(1)
 		return
 	}
+	widget.Focus()
+}
 
-	// if we get here there was nothing to focus on so focus on first widget
-	w.focus = -1
-	w.focusWidget()
+// focusNext focuses on the next available widget.
+// focusNext shall be called from queue context.
+func (w *Window) focusNext() {
+	w.focusStep(1)
 }
 
 // FocusNext focuses on the next available widget.
@@ -187,41 +462,10 @@ func (w *Window) FocusNext() {
 // focusPrevious focuses on the previous available widget.
 // focusPrevious shall be called from queue context.
 func (w *Window) focusPrevious() {
-	// it is ok to be negative since that'll focus on the first widget
-	w.focus--
-	if w.focus < 0 {
-		w.focusWidget()
-		return
-	}
-
-	// find previous widget
-	for i := w.focus; i > 0; i-- {
-		widget := w.widgets[i]
-		if !widget.CanFocus() {
-			continue
-		}
-		setCursor(-1, -1) // hide
-		w.focus = i
-		widget.Focus()
-		return
-	}
-
-	// if we get here we need to focus on last focusable widget
-	for i := len(w.widgets) - 1; i > w.focus; i-- {
-		widget := w.widgets[i]
-		if !widget.CanFocus() {
-			continue
-		}
-		setCursor(-1, -1) // hide
-		w.focus = i
-		widget.Focus()
-		return
-	}
-
-	// if we get here it means we found nothing usable and give up
+	w.focusStep(-1)
 }
 
-// FocusPrevious focuses on the next available widget.
+// FocusPrevious focuses on the previous available widget.
 func (w *Window) FocusPrevious() {
 	Queue(func() {
 		w.focusPrevious()
@@ -229,11 +473,48 @@ func (w *Window) FocusPrevious() {
 	})
 }
 
+// SetFocus focuses on the provided widget.  Nothing happens if the widget is
+// not part of the window or can not focus.
+func (w *Window) SetFocus(widget Widgeter) {
+	Queue(func() {
+		i := w.index(widget)
+		if i < 0 || !focusable(widget) {
+			return
+		}
+		w.focusIndex(i)
+		flush()
+	})
+}
+
+// SetTabOrder sets the order in which FocusNext and FocusPrevious visit the
+// widgets.  Widgets that are not listed are skipped but can still be focused
+// with SetFocus.  Without arguments the order in which the widgets were added
+// is restored.
+// SetTabOrder shall be called from queue context.
+func (w *Window) SetTabOrder(widgets ...Widgeter) {
+	w.tabOrder = nil
+	for _, widget := range widgets {
+		if w.index(widget) >= 0 {
+			w.tabOrder = append(w.tabOrder, widget)
+		}
+	}
+}
+
+// focusedWidget returns the focused widget or nil if there is none.
+// focusedWidget shall be called from queue context.
+func (w *Window) focusedWidget() Widgeter {
+	if w.focus < 0 || w.focus >= len(w.widgets) {
+		return nil
+	}
+	return w.widgets[w.focus]
+}
+
 // keyHandler routes event to proper widget.  This is called from queue context
 // so be careful to not use blocking calls.
-func (w *Window) keyHandler(ev termbox.Event) (bool, Windower, Widgeter) {
-	if w.focus < 0 || w.focus > len(w.widgets) {
+func (w *Window) keyHandler(ev Key) (bool, Windower, Widgeter) {
+	widget := w.focusedWidget()
+	if widget == nil || w.tooSmall {
 		return false, w.mgr, nil // not used
 	}
-	return w.widgets[w.focus].KeyHandler(ev), w.mgr, w.widgets[w.focus]
+	return widget.KeyHandler(ev), w.mgr, widget
 }